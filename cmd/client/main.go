@@ -17,6 +17,7 @@ func printHelp() {
 	fmt.Println("- help: print this help message.")
 	fmt.Println("- get <key:str>: get the value for the given key.")
 	fmt.Println("- put <key:str> <value:str> <ttl:int> <replication:int>: put the key value pair.")
+	fmt.Println("- trace <key:str>: trace the lookup of the node responsible for the given key.")
 	fmt.Println("- exit: exit the client.")
 	fmt.Println()
 }
@@ -70,6 +71,24 @@ func clientShell(address string) {
 			}
 			client.Put([]byte(key), []byte(value), uint16(ttl), uint8(replication))
 
+		case "trace":
+			if len(fields) != 2 {
+				fmt.Println("Syntax: trace <key:str>")
+				continue
+			}
+			trace, err := client.Trace([]byte(fields[1]))
+			if err != nil {
+				fmt.Println("error:", err)
+				continue
+			}
+			for i, hop := range trace.Hops {
+				status := ""
+				if hop.Failed {
+					status = " (unresponsive)"
+				}
+				fmt.Printf("%d: %s %x %v%s\n", i+1, hop.Addr, hop.Id, hop.Latency, status)
+			}
+			fmt.Printf("successor: %s %x, %d hops\n", trace.SuccessorAddr, trace.SuccessorId, len(trace.Hops))
 		case "help":
 			printHelp()
 		case "exit":
@@ -1,19 +1,120 @@
 package main
 
 import (
+	"DHT/internal/logger"
 	"DHT/internal/service"
+	"context"
 	"flag"
-	"log"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+// Here defines the exit codes of the dht.
+const (
+	EXIT_OK      = 0 // stopped gracefully on SIGINT or SIGTERM
+	EXIT_FAILURE = 1 // the server failed while serving
+	EXIT_CONFIG  = 2 // the server cannot be created, e.g. due to an invalid configuration
+	EXIT_FORCED  = 3 // the in-flight requests are not drained within the drain timeout, or a second signal is received
+)
+
+// checkConfig validates the configuration file with the overrides, and prints the effective configuration if valid,
+// returning the exit code.
+func checkConfig(configurationFile string, overrides map[string]string) int {
+	params, err := service.LoadParams(configurationFile, overrides)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return EXIT_CONFIG
+	}
+	fmt.Print(params.INI())
+	return EXIT_OK
+}
+
+// run runs the server of the configuration file with the overrides until SIGINT or SIGTERM, reloading the
+// configuration file on SIGHUP, and returns the exit code.
+func run(configurationFile string, overrides map[string]string) int {
+	server, err := service.NewServerWithOverrides(configurationFile, overrides)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return EXIT_CONFIG
+	}
+	defer logger.Sync()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve(ctx)
+	}()
+
+	var drain <-chan time.Time
+	for {
+		select {
+		case err := <-errs:
+			if err != nil {
+				logger.Logger.Errorw("server failed", "err", err)
+				fmt.Fprintln(os.Stderr, err)
+				return EXIT_FAILURE
+			}
+			logger.Logger.Infow("server stopped")
+			return EXIT_OK
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				// the log file may have been rotated, and the configuration file may have been changed
+				if err := logger.Reopen(); err != nil {
+					fmt.Fprintln(os.Stderr, "logger.Reopen error", err)
+				}
+				logger.Logger.Infow("log file reopened")
+				restart, err := server.Reload()
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+				} else if len(restart) > 0 {
+					fmt.Fprintln(os.Stderr, "config reloaded, but changes of", restart, "require a restart")
+				}
+				continue
+			}
+			if drain != nil {
+				logger.Logger.Warnw("forced to stop", "signal", sig)
+				return EXIT_FORCED
+			}
+			drainTimeout := server.CurrentParams().DrainTimeout
+			logger.Logger.Infow("stopping server", "signal", sig, "drainTimeout", drainTimeout)
+			fmt.Println("stopping server on", sig)
+			cancel()
+			drain = time.After(drainTimeout)
+		case <-drain:
+			logger.Logger.Warnw("server not drained in time")
+			return EXIT_FORCED
+		}
+	}
+}
+
 func main() {
 	var configurationFile string
-	flag.StringVar(&configurationFile, "c", "", "configuration file path")
+	var check bool
+	flag.StringVar(&configurationFile, "c", "", "configuration file path, optional if the required keys are given by flags or environment variables")
+	flag.BoolVar(&check, "check-config", false, "validate the configuration and print the effective one, without running the server")
+	// each key of the configuration file can be overridden by a flag of the same name
+	values := make(map[string]*string)
+	for _, key := range service.Keys {
+		values[key.Name] = flag.String(key.Name, "", key.Usage+", overriding the configuration file and $"+key.Env())
+	}
 	flag.Parse()
 
-	if len(configurationFile) == 0 {
-		log.Fatal("no argument configurationFile!")
+	overrides := make(map[string]string)
+	flag.Visit(func(f *flag.Flag) {
+		if value, ok := values[f.Name]; ok {
+			overrides[f.Name] = *value
+		}
+	})
+	if check {
+		os.Exit(checkConfig(configurationFile, overrides))
 	}
-	server := service.NewServer(configurationFile)
-	server.Serve()
+	os.Exit(run(configurationFile, overrides))
 }
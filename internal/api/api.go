@@ -7,30 +7,39 @@ import (
 	"DHT/internal/utils"
 	"context"
 	"encoding/binary"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"time"
 )
 
+// isTimeout returns whether the error is caused by a deadline exceeded on the P2P network.
+func isTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded
+}
+
 // Put the key/value pair into the storage expiring in `ttl` seconds,
 // and the pair should be replicated for `replication` times.
-func (s *ApiServer) Put(key []byte, value []byte, ttl uint16, replication uint8) {
+func (s *ApiServer) Put(key []byte, value []byte, ttl uint16, replication uint8) (err error) {
 	logger.Logger.Infow("api.Put", "key", string(key), "value", string(value), "ttl", ttl, "replication", replication)
-	var err error
 	defer func() {
 		if err != nil {
 			logger.Logger.Infow("api.Put error", "err", err)
 		}
 	}()
+	ctx, cancel := context.WithTimeout(context.Background(), s.p2pServer.RpcServer.Config().PutTimeout)
+	defer cancel()
 	// find successor
 	// initiate put request
 	expire := time.Now().Add(time.Second * time.Duration(ttl)).UnixMilli()
-	respNode, err := s.p2pServer.RpcServer.FindSuccessor(context.Background(), &proto.Id{Id: utils.SHA1(key)})
+	respNode, err := s.p2pServer.RpcServer.Lookup(ctx, utils.SHA1(key))
 	if err != nil {
-		return
+		return err
 	}
 	node := chord.NewNodeFromProtoNode(respNode)
-	c, err := node.GetClient(s.p2pServer.RpcServer.ClientCreds)
+	c, err := node.GetClient(s.p2pServer.RpcServer.Pool)
 	if err != nil {
-		return
+		return err
 	}
 	defer node.Close()
 	req := &proto.PutReq{
@@ -40,58 +49,166 @@ func (s *ApiServer) Put(key []byte, value []byte, ttl uint16, replication uint8)
 		InitiatorAddr: "",
 		Replication:   int32(replication),
 	}
-	resp, err := c.Put(context.Background(), req)
+	resp, err := c.Put(ctx, req)
 	logger.Logger.Infow("api.Put over", "node", node, "req", req, "resp", resp, "err", err)
+	return err
 }
 
 // Get finds the value for the given key, if any.
-func (s *ApiServer) Get(key []byte) ([]byte, bool) {
+func (s *ApiServer) Get(key []byte) (value []byte, ok bool, err error) {
 	logger.Logger.Infow("api.Get", "key", string(key))
-	var err error
 	defer func() {
 		if err != nil {
 			logger.Logger.Infow("api.Get error", "err", err)
 		}
 	}()
+	ctx, cancel := context.WithTimeout(context.Background(), s.p2pServer.RpcServer.Config().GetTimeout)
+	defer cancel()
 	// find successor
 	// initiate put
-	respNode, err := s.p2pServer.RpcServer.FindSuccessor(context.Background(), &proto.Id{Id: utils.SHA1(key)})
+	respNode, err := s.p2pServer.RpcServer.Lookup(ctx, utils.SHA1(key))
 	if err != nil {
-		return nil, false
+		return nil, false, err
 	}
 	node := chord.NewNodeFromProtoNode(respNode)
-	c, err := node.GetClient(s.p2pServer.RpcServer.ClientCreds)
+	c, err := node.GetClient(s.p2pServer.RpcServer.Pool)
 	if err != nil {
-		return nil, false
+		return nil, false, err
 	}
 	defer node.Close()
 	req := &proto.GetReq{Key: key}
-	resp, err := c.Get(context.Background(), req)
+	resp, err := c.Get(ctx, req)
 	if err != nil {
-		return nil, false
+		return nil, false, err
 	}
 	logger.Logger.Infow("api.Get over", "node", node, "req", req, "resp", resp, "err", err)
-	return resp.GetValue(), resp.GetOk()
+	return resp.GetValue(), resp.GetOk(), nil
+}
+
+// Touch extends the expiry of the key and its replicas to `ttl` seconds from now without re-sending the value,
+// and returns whether the key exists.
+func (s *ApiServer) Touch(key []byte, ttl uint16, replication uint8) (ok bool, err error) {
+	logger.Logger.Infow("api.Touch", "key", string(key), "ttl", ttl, "replication", replication)
+	defer func() {
+		if err != nil {
+			logger.Logger.Infow("api.Touch error", "err", err)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), s.p2pServer.RpcServer.Config().PutTimeout)
+	defer cancel()
+	expire := time.Now().Add(time.Second * time.Duration(ttl)).UnixMilli()
+	respNode, err := s.p2pServer.RpcServer.Lookup(ctx, utils.SHA1(key))
+	if err != nil {
+		return false, err
+	}
+	node := chord.NewNodeFromProtoNode(respNode)
+	c, err := node.GetClient(s.p2pServer.RpcServer.Pool)
+	if err != nil {
+		return false, err
+	}
+	defer node.Close()
+	req := &proto.TouchReq{
+		Key:         key,
+		Expire:      expire,
+		Replication: int32(replication),
+	}
+	resp, err := c.Touch(ctx, req)
+	if err != nil {
+		return false, err
+	}
+	logger.Logger.Infow("api.Touch over", "node", node, "req", req, "resp", resp, "err", err)
+	return resp.GetOk(), nil
+}
+
+// Delete deletes the key and its replicas, at most `replication` copies, and returns whether the key existed.
+func (s *ApiServer) Delete(key []byte, replication uint8) (ok bool, err error) {
+	logger.Logger.Infow("api.Delete", "key", string(key), "replication", replication)
+	defer func() {
+		if err != nil {
+			logger.Logger.Infow("api.Delete error", "err", err)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), s.p2pServer.RpcServer.Config().PutTimeout)
+	defer cancel()
+	respNode, err := s.p2pServer.RpcServer.Lookup(ctx, utils.SHA1(key))
+	if err != nil {
+		return false, err
+	}
+	node := chord.NewNodeFromProtoNode(respNode)
+	c, err := node.GetClient(s.p2pServer.RpcServer.Pool)
+	if err != nil {
+		return false, err
+	}
+	defer node.Close()
+	req := &proto.DeleteReq{
+		Key:         key,
+		Replication: int32(replication),
+	}
+	resp, err := c.Delete(ctx, req)
+	if err != nil {
+		return false, err
+	}
+	logger.Logger.Infow("api.Delete over", "node", node, "req", req, "resp", resp, "err", err)
+	return resp.GetOk(), nil
 }
 
 // ProcessMessage processes the given message, and return the response message, otherwise return 0, nil.
 func (s *ApiServer) ProcessMessage(msgType MsgType, msgBody []byte) (MsgType, []byte) {
+	switch msgType {
+	case DHT_PUT, DHT_PUT_IF_ABSENT, DHT_CAS, DHT_TOUCH, DHT_BATCH_PUT:
+		// the replication is always the 3rd byte of these messages
+		maxReplication := s.Config().MaxReplication
+		if maxReplication > 0 && len(msgBody) > 2 && int(msgBody[2]) > maxReplication {
+			logger.Logger.Warnw("replication too many", "msgType", msgType, "replication", msgBody[2])
+			return errorMessage(msgType, ERR_REPLICATION_TOO_MANY)
+		}
+	}
 	switch msgType {
 	case DHT_PUT:
 		TTL := binary.BigEndian.Uint16(msgBody[0:2])
 		replication := msgBody[2]
 		key := msgBody[4:36]
 		value := msgBody[36:]
-		s.Put(key, value, TTL, replication)
+		if err := s.Put(key, value, TTL, replication); isTimeout(err) {
+			return errorMessage(msgType, ERR_TIMEOUT)
+		}
 		return 0, nil
 	case DHT_GET:
 		key := msgBody[0:32]
-		if value, ok := s.Get(key); ok {
+		value, ok, err := s.Get(key)
+		if isTimeout(err) {
+			return errorMessage(msgType, ERR_TIMEOUT)
+		}
+		if ok {
 			data := key
 			data = append(data, value...)
 			return DHT_SUCCESS, data
 		}
 		return DHT_FAILURE, key
+	case DHT_TOUCH:
+		if len(msgBody) != 4+KEY_SIZE {
+			logger.Logger.Warnw("DHT_TOUCH error", "err", "message length mismatch")
+			return 0, nil
+		}
+		TTL := binary.BigEndian.Uint16(msgBody[0:2])
+		replication := msgBody[2]
+		key := msgBody[4 : 4+KEY_SIZE]
+		ok, err := s.Touch(key, TTL, replication)
+		if isTimeout(err) {
+			return errorMessage(msgType, ERR_TIMEOUT)
+		}
+		if ok {
+			return DHT_SUCCESS, key
+		}
+		return DHT_FAILURE, key
+	case DHT_TRACE:
+		return s.processTrace(msgBody)
+	case DHT_BATCH_GET:
+		return s.processBatchGet(msgBody)
+	case DHT_BATCH_PUT:
+		return s.processBatchPut(msgBody)
+	case DHT_PUT_IF_ABSENT, DHT_CAS:
+		return s.processCondPut(msgType, msgBody)
 	default:
 		return 0, nil
 	}
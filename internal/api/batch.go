@@ -0,0 +1,270 @@
+package api
+
+import (
+	"DHT/internal/chord"
+	"DHT/internal/chord/proto"
+	"DHT/internal/logger"
+	"DHT/internal/utils"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+const BATCH_LOOKUP_WORKERS = 8 // max number of the lookups of a batch running at once
+
+// keyGroup defines a group of keys in a batch, which the same node is responsible for.
+type keyGroup struct {
+	node    *chord.Node // the node responsible for the keys
+	indices []int       // the indices of the keys in the batch
+}
+
+// groupKeys groups the given keys by their responsible nodes within the deadline of ctx, the keys failed to be looked
+// up are not in any group. The ids of the keys are sorted and split into contiguous runs looked up concurrently by
+// at most BATCH_LOOKUP_WORKERS workers. Each run is visited in ascending order, so that a lookup is saved for the keys
+// whose ids fall between the last looked up id and the node found, which must have the same successor.
+func (s *ApiServer) groupKeys(ctx context.Context, keys [][]byte) []*keyGroup {
+	if len(keys) == 0 {
+		return nil
+	}
+	ids := make([][]byte, len(keys))
+	order := make([]int, len(keys))
+	for i, key := range keys {
+		ids[i] = utils.SHA1(key)
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return bytes.Compare(ids[order[a]], ids[order[b]]) < 0
+	})
+
+	size := (len(order) + BATCH_LOOKUP_WORKERS - 1) / BATCH_LOOKUP_WORKERS
+	runs := make([][]*keyGroup, (len(order)+size-1)/size) // the groups found in each run
+	wg := sync.WaitGroup{}
+	for r := range runs {
+		begin := r * size
+		end := begin + size
+		if end > len(order) {
+			end = len(order)
+		}
+		wg.Add(1)
+		go func(r int, run []int) {
+			defer wg.Done()
+			var last *keyGroup
+			var lastId []byte
+			for _, i := range run {
+				if last != nil && (bytes.Equal(ids[i], lastId) || utils.IsInRange(ids[i], lastId, last.node.Id)) {
+					last.indices = append(last.indices, i)
+					continue
+				}
+				respNode, err := s.p2pServer.RpcServer.Lookup(ctx, ids[i])
+				if err != nil {
+					logger.Logger.Infow("api.groupKeys error", "key", string(keys[i]), "err", err)
+					last = nil
+					continue
+				}
+				last, lastId = &keyGroup{node: chord.NewNodeFromProtoNode(respNode)}, ids[i]
+				last.indices = append(last.indices, i)
+				runs[r] = append(runs[r], last)
+			}
+		}(r, order[begin:end])
+	}
+	wg.Wait()
+
+	// the groups of the same node found by different lookups are merged
+	groups := make(map[string]*keyGroup)
+	var result []*keyGroup
+	for _, run := range runs {
+		for _, g := range run {
+			if merged, ok := groups[g.node.Ident()]; ok {
+				merged.indices = append(merged.indices, g.indices...)
+				continue
+			}
+			groups[g.node.Ident()] = g
+			result = append(result, g)
+		}
+	}
+	return result
+}
+
+// BatchPut puts all the key/value pairs into the storage expiring in `ttl` seconds, and each pair should be
+// replicated for `replication` times. It returns whether each pair is successfully put.
+func (s *ApiServer) BatchPut(keys [][]byte, values [][]byte, ttl uint16, replication uint8) []bool {
+	logger.Logger.Infow("api.BatchPut", "n", len(keys), "ttl", ttl, "replication", replication)
+	expire := time.Now().Add(time.Second * time.Duration(ttl)).UnixMilli()
+	oks := make([]bool, len(keys))
+	ctx, cancel := context.WithTimeout(context.Background(), s.p2pServer.RpcServer.Config().PutTimeout)
+	defer cancel()
+	wg := sync.WaitGroup{}
+	for _, g := range s.groupKeys(ctx, keys) {
+		wg.Add(1)
+		go func(g *keyGroup) {
+			defer wg.Done()
+			c, err := g.node.GetClient(s.p2pServer.RpcServer.Pool)
+			if err != nil {
+				logger.Logger.Infow("api.BatchPut error", "node", g.node, "err", err)
+				return
+			}
+			defer g.node.Close()
+			req := &proto.BatchPutReq{}
+			for _, i := range g.indices {
+				req.Reqs = append(req.Reqs, &proto.PutReq{
+					Key:         keys[i],
+					Value:       values[i],
+					Expire:      expire,
+					Replication: int32(replication),
+				})
+			}
+			if _, err := c.BatchPut(ctx, req); err != nil {
+				logger.Logger.Infow("api.BatchPut error", "node", g.node, "err", err)
+				return
+			}
+			for _, i := range g.indices {
+				oks[i] = true
+			}
+		}(g)
+	}
+	wg.Wait()
+	return oks
+}
+
+// BatchGet finds the values for all the given keys, and returns whether each key is found.
+func (s *ApiServer) BatchGet(keys [][]byte) ([][]byte, []bool) {
+	logger.Logger.Infow("api.BatchGet", "n", len(keys))
+	values := make([][]byte, len(keys))
+	oks := make([]bool, len(keys))
+	ctx, cancel := context.WithTimeout(context.Background(), s.p2pServer.RpcServer.Config().GetTimeout)
+	defer cancel()
+	wg := sync.WaitGroup{}
+	for _, g := range s.groupKeys(ctx, keys) {
+		wg.Add(1)
+		go func(g *keyGroup) {
+			defer wg.Done()
+			c, err := g.node.GetClient(s.p2pServer.RpcServer.Pool)
+			if err != nil {
+				logger.Logger.Infow("api.BatchGet error", "node", g.node, "err", err)
+				return
+			}
+			defer g.node.Close()
+			req := &proto.BatchGetReq{}
+			for _, i := range g.indices {
+				req.Keys = append(req.Keys, keys[i])
+			}
+			resp, err := c.BatchGet(ctx, req)
+			if err != nil {
+				logger.Logger.Infow("api.BatchGet error", "node", g.node, "err", err)
+				return
+			}
+			if len(resp.GetResps()) != len(g.indices) {
+				logger.Logger.Infow("api.BatchGet error", "node", g.node, "err", "number of responses mismatch")
+				return
+			}
+			for j, i := range g.indices {
+				values[i] = resp.Resps[j].GetValue()
+				oks[i] = resp.Resps[j].GetOk()
+			}
+		}(g)
+	}
+	wg.Wait()
+	return values, oks
+}
+
+// parseBatchGet parses the keys out from the body of a DHT_BATCH_GET message.
+func parseBatchGet(msgBody []byte) ([][]byte, error) {
+	if len(msgBody) < 4 {
+		return nil, errors.New("message too short")
+	}
+	count := int(binary.BigEndian.Uint16(msgBody[0:2]))
+	if count > MAX_BATCH_GET_KEYS {
+		return nil, errors.New("too many keys")
+	}
+	if len(msgBody) != 4+count*KEY_SIZE {
+		return nil, errors.New("message length mismatch")
+	}
+	keys := make([][]byte, count)
+	for i := range keys {
+		keys[i] = msgBody[4+i*KEY_SIZE : 4+(i+1)*KEY_SIZE]
+	}
+	return keys, nil
+}
+
+// parseBatchPut parses the ttl, the replication and the key/value pairs out from the body of a DHT_BATCH_PUT message.
+func parseBatchPut(msgBody []byte) (keys [][]byte, values [][]byte, ttl uint16, replication uint8, err error) {
+	if len(msgBody) < 8 {
+		return nil, nil, 0, 0, errors.New("message too short")
+	}
+	ttl = binary.BigEndian.Uint16(msgBody[0:2])
+	replication = msgBody[2]
+	count := int(binary.BigEndian.Uint16(msgBody[4:6]))
+	data := msgBody[8:]
+	for i := 0; i < count; i++ {
+		if len(data) < KEY_SIZE+2 {
+			return nil, nil, 0, 0, errors.New("message too short")
+		}
+		size := int(binary.BigEndian.Uint16(data[KEY_SIZE : KEY_SIZE+2]))
+		if len(data) < KEY_SIZE+2+size {
+			return nil, nil, 0, 0, errors.New("message too short")
+		}
+		keys = append(keys, data[:KEY_SIZE])
+		values = append(values, data[KEY_SIZE+2:KEY_SIZE+2+size])
+		data = data[KEY_SIZE+2+size:]
+	}
+	if len(data) != 0 {
+		return nil, nil, 0, 0, errors.New("message length mismatch")
+	}
+	return keys, values, ttl, replication, nil
+}
+
+// processBatchGet processes a DHT_BATCH_GET message, and returns the DHT_BATCH_GET_RESULT message.
+func (s *ApiServer) processBatchGet(msgBody []byte) (MsgType, []byte) {
+	keys, err := parseBatchGet(msgBody)
+	if err != nil {
+		logger.Logger.Warnw("parseBatchGet error", "err", err)
+		return 0, nil
+	}
+	values, oks := s.BatchGet(keys)
+	buf := bytes.NewBuffer([]byte{})
+	binary.Write(buf, binary.BigEndian, uint16(len(keys))) // count
+	binary.Write(buf, binary.BigEndian, uint16(0))         // reserved
+	for i, key := range keys {
+		status, value := BATCH_FAILURE, []byte(nil)
+		if oks[i] {
+			status, value = BATCH_SUCCESS, values[i]
+		}
+		// keep enough room for the rest keys, so that each key is answered
+		if 4+buf.Len()+KEY_SIZE+4+len(value)+(len(keys)-i-1)*(KEY_SIZE+4) > MAX_MSG_SIZE {
+			status, value = BATCH_OMITTED, nil
+		}
+		buf.Write(key)
+		buf.WriteByte(status)
+		buf.WriteByte(0) // reserved
+		binary.Write(buf, binary.BigEndian, uint16(len(value)))
+		buf.Write(value)
+	}
+	return DHT_BATCH_GET_RESULT, buf.Bytes()
+}
+
+// processBatchPut processes a DHT_BATCH_PUT message, and returns the DHT_BATCH_PUT_RESULT message.
+func (s *ApiServer) processBatchPut(msgBody []byte) (MsgType, []byte) {
+	keys, values, ttl, replication, err := parseBatchPut(msgBody)
+	if err != nil {
+		logger.Logger.Warnw("parseBatchPut error", "err", err)
+		return 0, nil
+	}
+	oks := s.BatchPut(keys, values, ttl, replication)
+	buf := bytes.NewBuffer([]byte{})
+	binary.Write(buf, binary.BigEndian, uint16(len(keys))) // count
+	binary.Write(buf, binary.BigEndian, uint16(0))         // reserved
+	for i, key := range keys {
+		buf.Write(key)
+		if oks[i] {
+			buf.WriteByte(BATCH_SUCCESS)
+		} else {
+			buf.WriteByte(BATCH_FAILURE)
+		}
+		buf.WriteByte(0) // reserved
+	}
+	return DHT_BATCH_PUT_RESULT, buf.Bytes()
+}
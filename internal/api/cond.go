@@ -0,0 +1,128 @@
+package api
+
+import (
+	"DHT/internal/chord"
+	"DHT/internal/chord/proto"
+	"DHT/internal/logger"
+	"DHT/internal/storage"
+	"DHT/internal/utils"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// CondPut puts the key/value pair into the storage expiring in `ttl` seconds only if the condition holds on the
+// responsible node, and the pair should be replicated for `replication` times once put.
+// It returns whether the pair is put, and the version and the value stored after the operation.
+func (s *ApiServer) CondPut(key []byte, value []byte, ttl uint16, replication uint8, cond storage.Condition) (ok bool, version uint64, current []byte, err error) {
+	logger.Logger.Infow("api.CondPut", "key", string(key), "value", string(value), "ttl", ttl, "replication", replication, "cond", cond)
+	defer func() {
+		if err != nil {
+			logger.Logger.Infow("api.CondPut error", "err", err)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), s.p2pServer.RpcServer.Config().PutTimeout)
+	defer cancel()
+	expire := time.Now().Add(time.Second * time.Duration(ttl)).UnixMilli()
+	respNode, err := s.p2pServer.RpcServer.Lookup(ctx, utils.SHA1(key))
+	if err != nil {
+		return false, 0, nil, err
+	}
+	node := chord.NewNodeFromProtoNode(respNode)
+	c, err := node.GetClient(s.p2pServer.RpcServer.Pool)
+	if err != nil {
+		return false, 0, nil, err
+	}
+	defer node.Close()
+	req := &proto.CondPutReq{
+		Req: &proto.PutReq{
+			Key:         key,
+			Value:       value,
+			Expire:      expire,
+			Replication: int32(replication),
+		},
+		Condition:       int32(cond.Kind),
+		ExpectedValue:   cond.Value,
+		ExpectedVersion: cond.Version,
+	}
+	resp, err := c.CondPut(ctx, req)
+	if err != nil {
+		return false, 0, nil, err
+	}
+	logger.Logger.Infow("api.CondPut over", "node", node, "req", req, "resp", resp)
+	return resp.GetOk(), resp.GetVersion(), resp.GetValue(), nil
+}
+
+// parseCas parses the ttl, the replication, the key, the new value and the condition out from the body of a DHT_CAS message.
+func parseCas(msgBody []byte) (ttl uint16, replication uint8, key []byte, value []byte, cond storage.Condition, err error) {
+	if len(msgBody) < 4+KEY_SIZE {
+		return 0, 0, nil, nil, cond, errors.New("message too short")
+	}
+	ttl = binary.BigEndian.Uint16(msgBody[0:2])
+	replication = msgBody[2]
+	key = msgBody[4 : 4+KEY_SIZE]
+	data := msgBody[4+KEY_SIZE:]
+	switch msgBody[3] {
+	case CAS_BY_VERSION:
+		if len(data) < 8 {
+			return 0, 0, nil, nil, cond, errors.New("message too short")
+		}
+		cond = storage.Condition{Kind: storage.COND_VERSION, Version: binary.BigEndian.Uint64(data[0:8])}
+		value = data[8:]
+	case CAS_BY_VALUE:
+		if len(data) < 4 {
+			return 0, 0, nil, nil, cond, errors.New("message too short")
+		}
+		size := int(binary.BigEndian.Uint16(data[0:2]))
+		if len(data) < 4+size {
+			return 0, 0, nil, nil, cond, errors.New("message too short")
+		}
+		cond = storage.Condition{Kind: storage.COND_VALUE, Value: data[4 : 4+size]}
+		value = data[4+size:]
+	default:
+		return 0, 0, nil, nil, cond, errors.New("unknown mode")
+	}
+	return ttl, replication, key, value, cond, nil
+}
+
+// processCondPut processes a DHT_PUT_IF_ABSENT or a DHT_CAS message, and returns the DHT_COND_SUCCESS or DHT_COND_FAILURE message.
+func (s *ApiServer) processCondPut(msgType MsgType, msgBody []byte) (MsgType, []byte) {
+	var ttl uint16
+	var replication uint8
+	var key, value []byte
+	var cond storage.Condition
+	if msgType == DHT_PUT_IF_ABSENT {
+		if len(msgBody) < 4+KEY_SIZE {
+			logger.Logger.Warnw("parsePutIfAbsent error", "err", "message too short")
+			return 0, nil
+		}
+		ttl = binary.BigEndian.Uint16(msgBody[0:2])
+		replication = msgBody[2]
+		key = msgBody[4 : 4+KEY_SIZE]
+		value = msgBody[4+KEY_SIZE:]
+		cond = storage.Condition{Kind: storage.COND_ABSENT}
+	} else {
+		var err error
+		if ttl, replication, key, value, cond, err = parseCas(msgBody); err != nil {
+			logger.Logger.Warnw("parseCas error", "err", err)
+			return 0, nil
+		}
+	}
+	ok, version, current, err := s.CondPut(key, value, ttl, replication, cond)
+	if isTimeout(err) {
+		return errorMessage(msgType, ERR_TIMEOUT)
+	}
+	if err != nil {
+		return DHT_FAILURE, key
+	}
+	buf := bytes.NewBuffer([]byte{})
+	buf.Write(key)
+	binary.Write(buf, binary.BigEndian, version)
+	if ok {
+		return DHT_COND_SUCCESS, buf.Bytes()
+	}
+	buf.Write(current)
+	return DHT_COND_FAILURE, buf.Bytes()
+}
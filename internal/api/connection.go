@@ -4,32 +4,56 @@ import (
 	"DHT/internal/logger"
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/binary"
 	"errors"
 	"io"
 	"net"
 	"sync"
+	"time"
 )
 
 // Connection defines a connection to a client.
 type Connection struct {
-	s        *ApiServer    // the ApiServer who creates this Connection
-	conn     net.Conn      // the net.Conn which the Connection is listening to
-	reader   *bufio.Reader // the bufio.Reader for the conn
-	sendLock sync.Mutex    // the sync.Mutex for sending any messages to the client
+	s        *ApiServer     // the ApiServer who creates this Connection
+	conn     net.Conn       // the net.Conn which the Connection is listening to
+	reader   *bufio.Reader  // the bufio.Reader for the conn
+	sendLock sync.Mutex     // the sync.Mutex for sending any messages to the client
+	ip       string         // the IP of the client, used for rate limiting
+	inFlight chan struct{}  // the semaphore limiting in-flight requests, nil if unlimited
+	requests sync.WaitGroup // waits for the in-flight requests before the connection is closed
+
+	ctx       context.Context               // the context canceled once the connection is closed
+	cancel    context.CancelFunc            // the function canceling ctx
+	subs      map[string]context.CancelFunc // the functions canceling the subscriptions, keyed by the subscribed key
+	subsMutex sync.Mutex
+	watches   sync.WaitGroup // waits for the goroutines of the subscriptions before the connection is cleared
 }
 
 // NewConnection creates a connection to a client of the given net.Conn object.
 func NewConnection(s *ApiServer, conn net.Conn) *Connection {
-	return &Connection{s: s, conn: conn, reader: bufio.NewReader(conn)}
+	p := &Connection{s: s, conn: conn, reader: bufio.NewReader(conn), subs: make(map[string]context.CancelFunc)}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	p.ip, _, _ = net.SplitHostPort(conn.RemoteAddr().String())
+	if maxInFlight := s.Config().MaxInFlight; maxInFlight > 0 {
+		p.inFlight = make(chan struct{}, maxInFlight)
+	}
+	return p
 }
 
 // threadReceiveMsg listens to a client and handle incoming requests.
 func (p *Connection) threadReceiveMsg() {
 	defer func() {
+		// answer the in-flight requests before closing the connection
+		p.requests.Wait()
 		logger.Logger.Infow("connection closed!", "addr", p.conn.RemoteAddr())
+		// stop the subscriptions, whose notifications being sent fail once the connection is closed
+		p.cancel()
 		p.conn.Close()
+		p.watches.Wait()
+		p.sendLock.Lock()
 		p.conn = nil
+		p.sendLock.Unlock()
 	}()
 
 	for true {
@@ -38,7 +62,64 @@ func (p *Connection) threadReceiveMsg() {
 			break
 		}
 		logger.Logger.Infow("readMessage", "msgType", msgType, "msgBody", string(msgBody), "addr", p.conn.RemoteAddr())
-		go p.handleMessage(msgType, msgBody)
+		if !p.s.limiter.Allow(p.ip) {
+			p.sendError(msgType, ERR_RATE_LIMITED)
+			continue
+		}
+		if !p.acquire() {
+			p.sendError(msgType, ERR_TOO_MANY_REQUESTS)
+			continue
+		}
+		p.requests.Add(1)
+		go func(msgType MsgType, msgBody []byte) {
+			defer p.requests.Done()
+			defer p.release()
+			p.handleMessage(msgType, msgBody)
+		}(msgType, msgBody)
+	}
+}
+
+// acquire takes a slot of in-flight requests, returns false if no slot is available.
+func (p *Connection) acquire() bool {
+	if p.inFlight == nil {
+		return true
+	}
+	select {
+	case p.inFlight <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// release gives back a slot of in-flight requests taken by acquire.
+func (p *Connection) release() {
+	if p.inFlight != nil {
+		<-p.inFlight
+	}
+}
+
+// reject tells the client that its connection is rejected, then closes the connection.
+func (p *Connection) reject(code ErrCode) {
+	defer p.cancel()
+	p.sendError(0, code)
+	p.conn.Close()
+}
+
+// errorMessage returns the DHT_ERROR message telling that a request of the given message type is rejected for the given reason.
+func errorMessage(msgType MsgType, code ErrCode) (MsgType, []byte) {
+	body := make([]byte, 4)
+	binary.BigEndian.PutUint16(body[0:2], uint16(msgType))
+	binary.BigEndian.PutUint16(body[2:4], uint16(code))
+	return DHT_ERROR, body
+}
+
+// sendError tells the client that its request of the given message type is rejected for the given reason.
+func (p *Connection) sendError(msgType MsgType, code ErrCode) {
+	logger.Logger.Warnw("request rejected", "msgType", msgType, "code", code, "addr", p.conn.RemoteAddr())
+	p.s.metrics.RejectApiRequest(code.String())
+	if err := p.sendMessage(errorMessage(msgType, code)); err != nil {
+		logger.Logger.Warnw("sendMessage error", "err", err, "addr", p.conn.RemoteAddr())
 	}
 }
 
@@ -49,7 +130,24 @@ func (p *Connection) handleMessage(msgType MsgType, msgBody []byte) {
 			logger.Logger.Errorw("panic when handleMessage", "err", err, "msgType", msgType, "msgBody", string(msgBody))
 		}
 	}()
-	respMsgType, respMsgBody := p.s.ProcessMessage(msgType, msgBody)
+	var respMsgType MsgType
+	var respMsgBody []byte
+	start := time.Now()
+	switch msgType {
+	case DHT_SUBSCRIBE, DHT_UNSUBSCRIBE:
+		respMsgType, respMsgBody = p.processSubscription(msgType, msgBody)
+	default:
+		respMsgType, respMsgBody = p.s.ProcessMessage(msgType, msgBody)
+	}
+	response := respMsgType.String()
+	if respMsgType == 0 {
+		// e.g. the subscriptions are answered by notifications only
+		response = "NONE"
+	}
+	p.s.metrics.ObserveApiRequest(msgType.String(), response, time.Since(start))
+	if respMsgType == DHT_ERROR && len(respMsgBody) >= 4 {
+		p.s.metrics.RejectApiRequest(ErrCode(binary.BigEndian.Uint16(respMsgBody[2:4])).String())
+	}
 	if respMsgType != 0 {
 		logger.Logger.Infow("sendMessage", "msgType", respMsgType, "msgBody", string(respMsgBody), "addr", p.conn.RemoteAddr())
 		if err := p.sendMessage(respMsgType, respMsgBody); err != nil {
@@ -61,7 +159,7 @@ func (p *Connection) handleMessage(msgType MsgType, msgBody []byte) {
 // readMessage reads an incoming request, return its message type in MsgType and message body in []byte.
 func (p *Connection) readMessage() (MsgType, []byte) {
 	data := make([]byte, 4)
-	if n, err := p.reader.Read(data); err != nil || n != 4 {
+	if n, err := io.ReadFull(p.reader, data); err != nil {
 		if err == io.EOF {
 			return 0, nil
 		}
@@ -70,9 +168,13 @@ func (p *Connection) readMessage() (MsgType, []byte) {
 	}
 	size := binary.BigEndian.Uint16(data[0:2])
 	msgType := binary.BigEndian.Uint16(data[2:4])
+	if size < 4 {
+		logger.Logger.Warnw("readMessage header error", "size", size)
+		return 0, nil
+	}
 
 	data = make([]byte, size-4)
-	if n, err := p.reader.Read(data); err != nil || n != int(size)-4 {
+	if n, err := io.ReadFull(p.reader, data); err != nil {
 		logger.Logger.Warnw("readMessage body error", "n", n, "err", err)
 		return 0, nil
 	}
@@ -85,6 +187,9 @@ func (p *Connection) sendMessage(msgType MsgType, msgBody []byte) error {
 	defer p.sendLock.Unlock()
 	buf := bytes.NewBuffer([]byte{})
 	size := 4 + len(msgBody)
+	if size > MAX_MSG_SIZE {
+		return errors.New("message too large")
+	}
 	if err := binary.Write(buf, binary.BigEndian, uint16(size)); err != nil {
 		return err
 	}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket defines a token bucket, refilled at `rate` tokens per second up to `burst` tokens.
+type tokenBucket struct {
+	tokens   float64   // the number of tokens currently available
+	lastTime time.Time // the last time the bucket was refilled
+}
+
+// allow refills the bucket until now, and takes one token if available.
+func (b *tokenBucket) allow(now time.Time, rate float64, burst int) bool {
+	b.tokens += now.Sub(b.lastTime).Seconds() * rate
+	if b.tokens > float64(burst) {
+		b.tokens = float64(burst)
+	}
+	b.lastTime = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// rateLimiter defines a token-bucket rate limiter keyed by remote IP.
+type rateLimiter struct {
+	rate      float64                 // the number of requests allowed per second, non-positive means unlimited, guarded by mutex as below
+	burst     int                     // the max burst size of requests
+	buckets   map[string]*tokenBucket // the token buckets of each remote IP
+	lastSweep time.Time               // the last time the idle buckets were removed
+	mutex     sync.Mutex
+}
+
+// newRateLimiter creates a rateLimiter allowing `rate` requests per second with bursts of `burst` requests for each IP.
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	l := &rateLimiter{
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+	l.setLimit(rate, burst)
+	return l
+}
+
+// setLimit changes the rate and the burst size of the limiter, which apply to the existing buckets as well.
+func (l *rateLimiter) setLimit(rate float64, burst int) {
+	if burst <= 0 {
+		burst = int(rate + 0.5)
+		if burst < 1 {
+			burst = 1
+		}
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.rate, l.burst = rate, burst
+}
+
+// Allow returns whether a request from the given IP is allowed now.
+func (l *rateLimiter) Allow(ip string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.rate <= 0 {
+		return true
+	}
+	now := time.Now()
+	l.sweep(now)
+	b, ok := l.buckets[ip]
+	if !ok {
+		b = &tokenBucket{tokens: float64(l.burst), lastTime: now}
+		l.buckets[ip] = b
+	}
+	return b.allow(now, l.rate, l.burst)
+}
+
+// sweep removes the buckets which have been idle long enough to be full again, so that they cost no memory.
+func (l *rateLimiter) sweep(now time.Time) {
+	const SWEEP_INTERVAL = time.Minute
+	if now.Sub(l.lastSweep) < SWEEP_INTERVAL {
+		return
+	}
+	l.lastSweep = now
+	refill := time.Duration(float64(l.burst) / l.rate * float64(time.Second))
+	for ip, b := range l.buckets {
+		if now.Sub(b.lastTime) > refill {
+			delete(l.buckets, ip)
+		}
+	}
+}
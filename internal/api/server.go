@@ -3,49 +3,135 @@ package api
 import (
 	"DHT/internal/chord"
 	"DHT/internal/logger"
-	"fmt"
-	"log"
+	"DHT/internal/metrics"
+	"context"
 	"net"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// Config defines the limits applied to the clients of an ApiServer, in which a non-positive value means unlimited.
+type Config struct {
+	MaxConnections int     // the max number of concurrent client connections
+	MaxInFlight    int     // the max number of in-flight requests on each connection
+	RateLimit      float64 // the max number of requests per second from each remote IP
+	RateBurst      int     // the max burst size of requests from each remote IP
+	MaxReplication int     // the max replication of data items accepted
+}
+
 // ApiServer defines a server handling api requests of clients by creating .
 type ApiServer struct {
 	p2pServer *chord.P2pServer // the underlying chord.P2pServer of the ApiServer
 	l         net.Listener     // the net.Listener that the ApiServer is listening on
-	stopped   bool             // whether the ApiServer has stopped
+	config    Config           // the limits applied to the clients, guarded by configMutex
+	limiter   *rateLimiter     // the rate limiter of requests keyed by remote IP
+	numConns  int32            // the number of current client connections
+	metrics   *metrics.Metrics // records the requests, nil if disabled
+
+	configMutex sync.RWMutex
+
+	conns      map[*Connection]net.Conn // the current client connections, stopped reading on shutdown
+	connsMutex sync.Mutex
+	wg         sync.WaitGroup // waits for the client connections to drain on shutdown
 }
 
-// NewApiServer creates a ApiServer with the given underlying chord.P2pServer, listening on the given address.
-func NewApiServer(p2pServer *chord.P2pServer, address string) *ApiServer {
+// NewApiServer creates a ApiServer with the given underlying chord.P2pServer, listening on the given address, and
+// recording the requests in the given metrics.Metrics if not nil.
+func NewApiServer(p2pServer *chord.P2pServer, address string, config Config, m *metrics.Metrics) (*ApiServer, error) {
 	l, err := net.Listen("tcp4", address)
 	if err != nil {
-		log.Fatal("ApiServer net.Listen error", err)
+		return nil, err
 	}
 	return &ApiServer{
 		p2pServer: p2pServer,
 		l:         l,
-		stopped:   false,
+		config:    config,
+		limiter:   newRateLimiter(config.RateLimit, config.RateBurst),
+		metrics:   m,
+		conns:     make(map[*Connection]net.Conn),
+	}, nil
+}
+
+// NumConnections returns the number of current client connections.
+func (s *ApiServer) NumConnections() int {
+	return int(atomic.LoadInt32(&s.numConns))
+}
+
+// Config returns the limits applied to the clients.
+func (s *ApiServer) Config() Config {
+	s.configMutex.RLock()
+	defer s.configMutex.RUnlock()
+	return s.config
+}
+
+// SetConfig replaces the limits applied to the clients while running. The MaxInFlight applies to the connections
+// accepted afterwards, while the other limits apply at once.
+func (s *ApiServer) SetConfig(config Config) {
+	s.configMutex.Lock()
+	s.config = config
+	s.configMutex.Unlock()
+	s.limiter.setLimit(config.RateLimit, config.RateBurst)
+}
+
+// Close releases the listener of an ApiServer which is never served. An ApiServer served releases it once Serve
+// returns.
+func (s *ApiServer) Close() error {
+	return s.l.Close()
+}
+
+// Serve accepts incoming connections until the given context is canceled. On shutdown, it stops reading requests
+// from the clients, and returns once the in-flight requests are answered and the connections are closed.
+func (s *ApiServer) Serve(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		s.l.Close()
+	}()
+	err := s.accept()
+	if ctx.Err() != nil {
+		err = nil
 	}
+	cancel()
+	// stop reading requests, so that each connection is closed once its in-flight requests are answered
+	s.connsMutex.Lock()
+	for _, c := range s.conns {
+		c.SetReadDeadline(time.Now())
+	}
+	s.connsMutex.Unlock()
+	s.wg.Wait()
+	logger.Logger.Debugw("ApiServer stopped")
+	return err
 }
 
-// Serve accepts incoming connections.
-func (s *ApiServer) Serve() error {
+// accept accepts incoming connections until the listener is closed.
+func (s *ApiServer) accept() error {
 	for {
 		c, err := s.l.Accept()
 		if err != nil {
-			if s.stopped {
-				fmt.Println("ApiServer stopped!")
-				return nil
-			}
 			return err
 		}
+		conn := NewConnection(s, c)
+		maxConns := s.Config().MaxConnections
+		if n := atomic.AddInt32(&s.numConns, 1); maxConns > 0 && int(n) > maxConns {
+			atomic.AddInt32(&s.numConns, -1)
+			logger.Logger.Warnw("connection rejected", "addr", c.RemoteAddr(), "numConns", n-1)
+			go conn.reject(ERR_TOO_MANY_CONNECTIONS)
+			continue
+		}
 		logger.Logger.Infow("connection accepted", "addr", c.RemoteAddr())
-		go NewConnection(s, c).threadReceiveMsg()
+		s.connsMutex.Lock()
+		s.conns[conn] = c
+		s.connsMutex.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			conn.threadReceiveMsg()
+			atomic.AddInt32(&s.numConns, -1)
+			s.connsMutex.Lock()
+			delete(s.conns, conn)
+			s.connsMutex.Unlock()
+		}()
 	}
 }
-
-// Stop stops the ApiServer.
-func (s *ApiServer) Stop() {
-	s.stopped = true
-	s.l.Close()
-}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"DHT/internal/chord/proto"
+	"DHT/internal/logger"
+	"DHT/internal/utils"
+	"bytes"
+	"context"
+	"encoding/binary"
+)
+
+// Trace looks up the node responsible for the key, and returns the hops visited.
+func (s *ApiServer) Trace(key []byte) (*proto.Trace, error) {
+	logger.Logger.Infow("api.Trace", "key", string(key))
+	trace, err := s.p2pServer.RpcServer.LookupTrace(context.Background(), utils.SHA1(key))
+	if err != nil {
+		logger.Logger.Infow("api.Trace error", "err", err)
+		return nil, err
+	}
+	logger.Logger.Infow("api.Trace over", "key", string(key), "trace", trace)
+	return trace, nil
+}
+
+// processTrace processes a DHT_TRACE message, and returns the DHT_TRACE_RESULT message.
+func (s *ApiServer) processTrace(msgBody []byte) (MsgType, []byte) {
+	if len(msgBody) != KEY_SIZE {
+		logger.Logger.Warnw("processTrace error", "err", "message length mismatch")
+		return 0, nil
+	}
+	key := msgBody[0:KEY_SIZE]
+	trace, err := s.Trace(key)
+	if isTimeout(err) {
+		return errorMessage(DHT_TRACE, ERR_TIMEOUT)
+	}
+	if err != nil {
+		return DHT_FAILURE, key
+	}
+	buf := bytes.NewBuffer([]byte{})
+	buf.Write(key)
+	buf.Write(trace.GetSuccessor().GetId())
+	binary.Write(buf, binary.BigEndian, uint16(len(trace.GetSuccessor().GetAddr())))
+	binary.Write(buf, binary.BigEndian, uint16(len(trace.GetHops())))
+	buf.WriteString(trace.GetSuccessor().GetAddr())
+	for _, hop := range trace.GetHops() {
+		binary.Write(buf, binary.BigEndian, uint32(hop.GetLatency()))
+		if hop.GetFailed() {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+		buf.WriteByte(0) // reserved
+		binary.Write(buf, binary.BigEndian, uint16(len(hop.GetNode().GetAddr())))
+		buf.Write(hop.GetNode().GetId())
+		buf.WriteString(hop.GetNode().GetAddr())
+	}
+	return DHT_TRACE_RESULT, buf.Bytes()
+}
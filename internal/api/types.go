@@ -1,5 +1,12 @@
 package api
 
+const KEY_SIZE = 32            // #bytes of keys in messages
+const MAX_MSG_SIZE = 1<<16 - 1 // max #bytes of a message, including the header
+
+// MAX_BATCH_GET_KEYS is the max number of keys in a DHT_BATCH_GET, so that the DHT_BATCH_GET_RESULT can answer each
+// key within MAX_MSG_SIZE, at least by BATCH_OMITTED.
+const MAX_BATCH_GET_KEYS = (MAX_MSG_SIZE - 8) / (KEY_SIZE + 4)
+
 // MsgType is a type defining the message type of any messages when communicating with clients.
 type MsgType uint16
 
@@ -9,4 +16,108 @@ const (
 	DHT_GET     MsgType = 651
 	DHT_SUCCESS MsgType = 652
 	DHT_FAILURE MsgType = 653
+	DHT_ERROR   MsgType = 654
+
+	DHT_BATCH_GET        MsgType = 655
+	DHT_BATCH_GET_RESULT MsgType = 656
+	DHT_BATCH_PUT        MsgType = 657
+	DHT_BATCH_PUT_RESULT MsgType = 658
+
+	DHT_PUT_IF_ABSENT MsgType = 659
+	DHT_CAS           MsgType = 660
+	DHT_COND_SUCCESS  MsgType = 661
+	DHT_COND_FAILURE  MsgType = 662
+
+	DHT_SUBSCRIBE    MsgType = 663
+	DHT_UNSUBSCRIBE  MsgType = 664
+	DHT_NOTIFICATION MsgType = 665
+
+	DHT_TOUCH MsgType = 666
+
+	DHT_TRACE        MsgType = 667
+	DHT_TRACE_RESULT MsgType = 668
+)
+
+// msgTypeNames defines the names of all the message types, used in metrics.
+var msgTypeNames = map[MsgType]string{
+	DHT_PUT:              "DHT_PUT",
+	DHT_GET:              "DHT_GET",
+	DHT_SUCCESS:          "DHT_SUCCESS",
+	DHT_FAILURE:          "DHT_FAILURE",
+	DHT_ERROR:            "DHT_ERROR",
+	DHT_BATCH_GET:        "DHT_BATCH_GET",
+	DHT_BATCH_GET_RESULT: "DHT_BATCH_GET_RESULT",
+	DHT_BATCH_PUT:        "DHT_BATCH_PUT",
+	DHT_BATCH_PUT_RESULT: "DHT_BATCH_PUT_RESULT",
+	DHT_PUT_IF_ABSENT:    "DHT_PUT_IF_ABSENT",
+	DHT_CAS:              "DHT_CAS",
+	DHT_COND_SUCCESS:     "DHT_COND_SUCCESS",
+	DHT_COND_FAILURE:     "DHT_COND_FAILURE",
+	DHT_SUBSCRIBE:        "DHT_SUBSCRIBE",
+	DHT_UNSUBSCRIBE:      "DHT_UNSUBSCRIBE",
+	DHT_NOTIFICATION:     "DHT_NOTIFICATION",
+	DHT_TOUCH:            "DHT_TOUCH",
+	DHT_TRACE:            "DHT_TRACE",
+	DHT_TRACE_RESULT:     "DHT_TRACE_RESULT",
+}
+
+// String returns the name of the MsgType, or UNKNOWN if not defined.
+func (t MsgType) String() string {
+	if name, ok := msgTypeNames[t]; ok {
+		return name
+	}
+	return "UNKNOWN"
+}
+
+// Here defines the status of each key in DHT_BATCH_GET_RESULT and DHT_BATCH_PUT_RESULT messages.
+const (
+	BATCH_FAILURE uint8 = 0 // the key is not found, or failed to be put
+	BATCH_SUCCESS uint8 = 1 // the key is found, or successfully put
+	BATCH_OMITTED uint8 = 2 // the value is omitted since the message would exceed MAX_MSG_SIZE, the key should be queried again
 )
+
+// ErrCode is a type defining the reason carried in a DHT_ERROR message, telling why a request was rejected.
+type ErrCode uint16
+
+// Here defines some ErrCode constants for all reasons of rejection.
+const (
+	ERR_TOO_MANY_CONNECTIONS ErrCode = 1 // the server has reached its max number of connections
+	ERR_TOO_MANY_REQUESTS    ErrCode = 2 // the connection has reached its max number of in-flight requests
+	ERR_RATE_LIMITED         ErrCode = 3 // the remote IP has exceeded its request rate
+	ERR_REPLICATION_TOO_MANY ErrCode = 4 // the replication of the request exceeds the max replication accepted
+	ERR_TIMEOUT              ErrCode = 5 // the request has timed out on the P2P network
+)
+
+// String returns the name of the ErrCode, used in metrics.
+func (c ErrCode) String() string {
+	switch c {
+	case ERR_TOO_MANY_CONNECTIONS:
+		return "too_many_connections"
+	case ERR_TOO_MANY_REQUESTS:
+		return "too_many_requests"
+	case ERR_RATE_LIMITED:
+		return "rate_limited"
+	case ERR_REPLICATION_TOO_MANY:
+		return "replication_too_many"
+	case ERR_TIMEOUT:
+		return "timeout"
+	}
+	return "unknown"
+}
+
+// Here defines the modes of DHT_CAS messages.
+const (
+	CAS_BY_VALUE   uint8 = 0 // swap if the current value equals the expected value
+	CAS_BY_VERSION uint8 = 1 // swap if the current version equals the expected version
+)
+
+// Here defines the types of changes in DHT_NOTIFICATION messages.
+const (
+	NOTIFY_PUT    uint8 = 0 // the key is put
+	NOTIFY_DELETE uint8 = 1 // the key is deleted
+	NOTIFY_EXPIRE uint8 = 2 // the key has expired
+)
+
+// NOTIFY_OMITTED is the flag of DHT_NOTIFICATION messages telling that the value is omitted since the message would
+// exceed MAX_MSG_SIZE, the key should be queried again.
+const NOTIFY_OMITTED uint8 = 1
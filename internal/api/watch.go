@@ -0,0 +1,135 @@
+package api
+
+import (
+	"DHT/internal/chord"
+	"DHT/internal/chord/proto"
+	"DHT/internal/logger"
+	"DHT/internal/utils"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"time"
+)
+
+const WATCH_RECHECK_INTERVAL = 5 * time.Second // the interval of checking whether the responsible node of a watched key has changed
+const WATCH_RETRY_INTERVAL = time.Second       // the interval of retrying to watch a key after failures
+
+// Watch watches the changes of the given key on its responsible node, and calls `fn` on each change until ctx is done.
+// The responsible node is looked up again periodically and whenever the stream breaks, so that the watch is
+// re-established on the new responsible node once the ownership of the key moves.
+func (s *ApiServer) Watch(ctx context.Context, key []byte, fn func(*proto.Event)) {
+	logger.Logger.Infow("api.Watch", "key", string(key))
+	id := utils.SHA1(key)
+	for ctx.Err() == nil {
+		if err := s.watchOnce(ctx, id, key, fn); err != nil && ctx.Err() == nil {
+			logger.Logger.Infow("api.Watch error", "key", string(key), "err", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(WATCH_RETRY_INTERVAL):
+			}
+		}
+	}
+	logger.Logger.Infow("api.Watch over", "key", string(key))
+}
+
+// watchOnce watches the changes of the given key on its current responsible node, until the stream breaks or the
+// responsible node changes.
+func (s *ApiServer) watchOnce(ctx context.Context, id []byte, key []byte, fn func(*proto.Event)) error {
+	respNode, err := s.p2pServer.RpcServer.Lookup(ctx, id)
+	if err != nil {
+		return err
+	}
+	node := chord.NewNodeFromProtoNode(respNode)
+	c, err := node.GetClient(s.p2pServer.RpcServer.Pool)
+	if err != nil {
+		return err
+	}
+	defer node.Close()
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.Watch(streamCtx, &proto.WatchReq{Key: key})
+	if err != nil {
+		return err
+	}
+
+	// cancel the stream once the responsible node changes
+	go func() {
+		ticker := time.NewTicker(WATCH_RECHECK_INTERVAL)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-streamCtx.Done():
+				return
+			case <-ticker.C:
+				n, err := s.p2pServer.RpcServer.Lookup(streamCtx, id)
+				if err == nil && chord.NewNodeFromProtoNode(n).Ident() != node.Ident() {
+					logger.Logger.Infow("api.Watch responsible node changed", "key", string(key), "from", node.Ident(), "to", chord.NewNodeFromProtoNode(n).Ident())
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		ev, err := stream.Recv()
+		if err != nil {
+			if streamCtx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		fn(ev)
+	}
+}
+
+// processSubscription processes a DHT_SUBSCRIBE or a DHT_UNSUBSCRIBE message on this connection, and returns the
+// DHT_SUCCESS message with the key as body.
+func (p *Connection) processSubscription(msgType MsgType, msgBody []byte) (MsgType, []byte) {
+	if len(msgBody) != KEY_SIZE {
+		logger.Logger.Warnw("parseSubscription error", "err", "message length mismatch")
+		return 0, nil
+	}
+	key := msgBody[:KEY_SIZE]
+	p.subsMutex.Lock()
+	defer p.subsMutex.Unlock()
+	cancel, exists := p.subs[string(key)]
+	if msgType == DHT_UNSUBSCRIBE {
+		if exists {
+			cancel()
+			delete(p.subs, string(key))
+		}
+		return DHT_SUCCESS, key
+	}
+	if !exists {
+		ctx, cancel := context.WithCancel(p.ctx)
+		p.subs[string(key)] = cancel
+		p.watches.Add(1)
+		go func() {
+			defer p.watches.Done()
+			p.s.Watch(ctx, key, func(ev *proto.Event) {
+				p.notify(key, ev)
+			})
+		}()
+	}
+	return DHT_SUCCESS, key
+}
+
+// notify sends the DHT_NOTIFICATION message of the given change of the subscribed key to the client. The value is
+// omitted if the message would exceed MAX_MSG_SIZE.
+func (p *Connection) notify(key []byte, ev *proto.Event) {
+	value, flags := ev.GetValue(), uint8(0)
+	if 4+12+KEY_SIZE+len(value) > MAX_MSG_SIZE {
+		value, flags = nil, NOTIFY_OMITTED
+	}
+	buf := bytes.NewBuffer([]byte{})
+	buf.WriteByte(uint8(ev.GetType()))                      // type
+	buf.WriteByte(flags)                                    // flags
+	buf.Write([]byte{0, 0})                                 // reserved
+	binary.Write(buf, binary.BigEndian, uint64(ev.Version)) // version
+	buf.Write(key)                                          // key
+	buf.Write(value)                                        // value
+	if err := p.sendMessage(DHT_NOTIFICATION, buf.Bytes()); err != nil {
+		logger.Logger.Warnw("sendMessage error", "err", err)
+	}
+}
@@ -3,6 +3,7 @@ package chord
 import (
 	"DHT/internal/chord/proto"
 	"DHT/internal/logger"
+	"DHT/internal/metrics"
 	"DHT/internal/storage"
 	"DHT/internal/utils"
 	"context"
@@ -15,21 +16,24 @@ import (
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
 	"io/ioutil"
-	"log"
 	"math/rand"
 	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // P2pServer defines a P2P server handling any requests from other P2P servers.
 type P2pServer struct {
-	RpcServer  *ChordRpcServer // the underlying rpc server of type ChordRpcServer
-	RpcService *grpc.Server    // the current running rpc service of the underlying rpc server
-	lis        net.Listener    // the net.Listener which the underlying RpcServer should listen on
-	stopped    bool            // whether the P2pServer has stopped
-	wg         *sync.WaitGroup // used for graceful shutdown
+	RpcServer      *ChordRpcServer   // the underlying rpc server of type ChordRpcServer, i.e. the virtual node 0
+	VirtualServers []*ChordRpcServer // the rpc servers of all our virtual nodes, indexed by Node.Vnode
+	RpcService     *grpc.Server      // the current running rpc service of the underlying rpc servers
+	Discoverer     Discoverer        // optional, discovers the bootstrappers if none is specified
+	lis            net.Listener      // the net.Listener which the underlying RpcServer should listen on
+	wg             *sync.WaitGroup   // used for graceful shutdown
+	joined         int32             // 1 once we have joined the Chord network, 0 before and after serving
+	expectPeers    int32             // 1 if any bootstrapper is configured or discovered, so that we should not be alone
 }
 
 // loadServerTLSCredentials loads TLS Credentials from the given cert and key for server
@@ -77,142 +81,218 @@ func loadClientTLSCredentials(caCertFile string, serverCert, serverKey string) (
 	}), nil
 }
 
-// NewP2pServer creates a new P2P server listening on the given address.
-func NewP2pServer(storage *storage.Storage, address string, caCert, serverCert, serverKey string) *P2pServer {
+// NewP2pServer creates a new P2P server listening on the given address, and recording the RPCs served, the lookups and
+// the maintenance errors in the given metrics.Metrics if not nil.
+func NewP2pServer(storage *storage.Storage, address string, caCert, serverCert, serverKey string, config Config, m *metrics.Metrics) (*P2pServer, error) {
 	serverCreds, err := loadServerTLSCredentials(caCert, serverCert, serverKey)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	clientCreds, err := loadClientTLSCredentials(caCert, serverCert, serverKey)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	lis, err := net.Listen("tcp", address)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	s := grpc.NewServer(grpc.Creds(serverCreds), grpc.UnaryInterceptor(m.UnaryServerInterceptor), grpc.StreamInterceptor(m.StreamServerInterceptor))
+	// all the virtual nodes share the listener, the storage and the connections to the other servers
+	pool := NewConnPool(clientCreds)
+	router := &vnodeRouter{}
+	for i := 0; i < config.VirtualNodes || i == 0; i++ {
+		server := NewVirtualChordServer(storage, address, int32(i), pool, config)
+		server.metrics = m
+		router.servers = append(router.servers, server)
 	}
-	s := grpc.NewServer(grpc.Creds(serverCreds))
-	server := NewChordServer(storage, address, clientCreds)
-	proto.RegisterChordServer(s, server)
+	proto.RegisterChordServer(s, router)
 	p2pServer := &P2pServer{
-		RpcServer:  server,
-		RpcService: s,
-		lis:        lis,
-		stopped:    false,
-		wg:         &sync.WaitGroup{},
+		RpcServer:      router.servers[0],
+		VirtualServers: router.servers,
+		RpcService:     s,
+		lis:            lis,
+		wg:             &sync.WaitGroup{},
 	}
-	return p2pServer
+	return p2pServer, nil
 }
 
-// Serve runs the chord service, and joins the existing Chord network if bootstrapper is specified, otherwise create a new Chord network.
-func (s *P2pServer) Serve(bootstrapper string) error {
+// Close releases the listener and the connections of a P2pServer which is never served, e.g. when the server it
+// belongs to fails to be created. A P2pServer served releases them once Serve returns.
+func (s *P2pServer) Close() {
+	s.RpcService.Stop()
+	s.lis.Close()
+	s.RpcServer.Pool.Close()
+}
+
+// SetConfig replaces the parameters of the Chord algorithm of all our virtual nodes while running.
+func (s *P2pServer) SetConfig(config Config) {
+	for _, server := range s.VirtualServers {
+		server.SetConfig(config)
+	}
+}
+
+// Serve runs the chord service, and joins the existing Chord network through the bootstrappers if any are specified,
+// otherwise create a new Chord network. It blocks until the given context is canceled or the service fails, then stops the maintenance
+// tasks, waits for the in-flight requests and closes the connections to the other servers.
+func (s *P2pServer) Serve(ctx context.Context, bootstrappers []string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make(chan error, 2)
+	go func() {
+		errs <- s.RpcService.Serve(s.lis)
+	}()
+	s.wg.Add(1)
+	go s.sweepConnections(ctx)
 	s.wg.Add(1)
 	go func(s *P2pServer) {
-		time.Sleep(time.Second)
-		if len(strings.TrimSpace(bootstrapper)) > 0 {
-			// Join the existing chord
-			if err := s.RpcServer.Join(context.Background(), NewNode(bootstrapper)); err != nil {
-				log.Fatal("server.Join error", err)
-			}
-			fmt.Println("server.Join ok!")
+		defer s.wg.Done()
+		if err := s.join(ctx, bootstrappers); err != nil {
+			errs <- err
+			return
 		}
-
-		// Stabilize
-		lastInfo := ""
-		for {
-			if s.stopped {
-				break
-			}
-			err := s.RpcServer.CheckPredecessorAndSuccessor(context.Background())
-			if err != nil {
-				if s.stopped {
-					break
-				}
-				logger.Logger.Warnw("server.Stabilize error", "err", err)
-			}
-			if s.stopped {
-				break
-			}
-			err = s.RpcServer.Stabilize(context.Background())
-			if err != nil {
-				if s.stopped {
-					break
-				}
-				logger.Logger.Warnw("server.Stabilize error", "err", err)
-			}
-			if s.stopped {
-				break
-			}
-			err = s.RpcServer.FixFingers(context.Background())
-			if err != nil {
-				if s.stopped {
-					break
-				}
-				logger.Logger.Warnw("server.FixFingers error", "err", err)
+		// each maintenance task of each virtual node runs on its own interval
+		for _, server := range s.VirtualServers {
+			for _, t := range s.tasks(server) {
+				s.wg.Add(1)
+				go s.runTask(ctx, server, t)
 			}
-			if s.stopped {
-				break
-			}
-			newInfo := s.RpcServer.GetInfoString()
-			if lastInfo != newInfo {
-				lastInfo = newInfo
-				fmt.Println(newInfo)
-			}
-			if s.stopped {
-				break
-			}
-			//time.Sleep(time.Second)
-			time.Sleep(time.Millisecond * 10)
 		}
-		fmt.Println("P2p server stopped!")
-		s.wg.Done()
 	}(s)
-	if err := s.RpcService.Serve(s.lis); err != nil {
-		return err
-	}
-
-	return nil
-}
 
-// Stop stops the P2pServer gracefully.
-func (s *P2pServer) Stop() {
-	s.stopped = true
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-errs:
+	}
+	cancel()
+	// the watches last until the watchers leave, which would block the graceful stop
+	for _, server := range s.VirtualServers {
+		server.watchers.stop()
+	}
 	s.RpcService.GracefulStop()
 	s.wg.Wait()
+	atomic.StoreInt32(&s.joined, 0)
+	atomic.StoreInt32(&s.expectPeers, 0)
+	s.RpcServer.Pool.Close()
+	logger.Logger.Debugw("P2p server stopped")
+	return err
+}
+
+// sweepConnections periodically evicts the idle and unhealthy connections from the ConnPool, until the context is canceled.
+func (s *P2pServer) sweepConnections(ctx context.Context) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(CONN_SWEEP_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.RpcServer.Pool.evict()
+		}
+	}
 }
 
 // ChordRpcServer defines a Chord server running the Chord algorithm.
 type ChordRpcServer struct {
 	proto.UnimplementedChordServer
-	storage       *storage.Storage
-	Self          *Node
-	Finger        []*Node
-	Predecessor   *Node
-	successorList []*Node
-	mutex         sync.Mutex
-	ClientCreds   credentials.TransportCredentials
+	storage         *storage.Storage
+	Self            *Node
+	finger          []*Node // our Finger table, guarded by mutex as all the ring state below
+	predecessor     *Node   // our Predecessor
+	predecessorList []*Node // our predecessors, the closest first, i.e. starting from the Predecessor
+	successorList   []*Node
+	mutex           sync.Mutex
+	Pool            *ConnPool // the connections to the other servers, shared by all our virtual nodes
+	watchers        watchers  // the watchers of keys in our storage
+	config          Config    // the parameters of the Chord algorithm, guarded by configMutex
+	configMutex     sync.RWMutex
+	stats           stats            // the statistics of the lookups initiated by us
+	ring            ringSignal       // wakes up the maintenance tasks on changes of the ring
+	detector        FailureDetector  // detects the failures of our Predecessor and successor
+	metrics         *metrics.Metrics // records the lookups and the maintenance errors, nil if disabled
 }
 
 // NewChordServer creates a new Chord server with the given underlying storage.Storage, listening on the given address.
-func NewChordServer(storage *storage.Storage, addr string, clientCreds credentials.TransportCredentials) *ChordRpcServer {
+func NewChordServer(storage *storage.Storage, addr string, pool *ConnPool, config Config) *ChordRpcServer {
+	return NewVirtualChordServer(storage, addr, 0, pool, config)
+}
+
+// NewVirtualChordServer creates a new Chord server of the given virtual node with the given underlying storage.Storage,
+// listening on the given address.
+func NewVirtualChordServer(storage *storage.Storage, addr string, vnode int32, pool *ConnPool, config Config) *ChordRpcServer {
 	s := &ChordRpcServer{
-		Self:        NewNode(addr),
-		Finger:      make([]*Node, M),
-		Predecessor: nil,
+		Self:        NewVirtualNode(addr, vnode),
+		finger:      make([]*Node, M),
+		predecessor: nil,
 		storage:     storage,
-		ClientCreds: clientCreds,
+		Pool:        pool,
+		config:      config.withDefaults(),
 	}
-	//s.Predecessor = s.Self
+	//s.predecessor = s.Self
 	for i := 0; i < M; i++ {
-		s.Finger[i] = s.Self
+		s.finger[i] = s.Self
 	}
+	storage.AddListener(s.watchers.onStorageEvent)
 	return s
 }
 
-// successor returns s.Finger[0], which is our successor.
+// Config returns the parameters of the Chord algorithm run by us, with the defaults filled in.
+func (s *ChordRpcServer) Config() Config {
+	s.configMutex.RLock()
+	defer s.configMutex.RUnlock()
+	return s.config
+}
+
+// SetConfig replaces the parameters of the Chord algorithm while running, and wakes up the maintenance tasks so that
+// the new intervals take effect at once. The VirtualNodes and SuccessorListSize are kept, since they cannot change
+// without a restart.
+func (s *ChordRpcServer) SetConfig(config Config) {
+	s.configMutex.Lock()
+	config.VirtualNodes, config.SuccessorListSize = s.config.VirtualNodes, s.config.SuccessorListSize
+	s.config = config.withDefaults()
+	s.configMutex.Unlock()
+	s.ring.broadcast()
+}
+
+// replicaSuccessor returns our first successor on another server, to which the replicas are forwarded,
+// since all the virtual nodes of a server share one storage. It returns nil if there is no such successor.
+func (s *ChordRpcServer) replicaSuccessor() *Node {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, node := range append([]*Node{s.successor()}, s.successorList...) {
+		if node != nil && node.Addr != s.Self.Addr {
+			return node
+		}
+	}
+	return nil
+}
+
+// Successor returns our successor.
+func (s *ChordRpcServer) Successor() *Node {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.successor()
+}
+
+// Predecessor returns our Predecessor, nil if unknown.
+func (s *ChordRpcServer) Predecessor() *Node {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.predecessor
+}
+
+// Finger returns the i-th entry of our Finger table, i.e. the successor of Self.Id + 2^i.
+func (s *ChordRpcServer) Finger(i int) *Node {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.finger[i]
+}
+
+// successor returns s.finger[0], which is our successor. It should be called with s.mutex held.
 func (s *ChordRpcServer) successor() *Node {
-	if len(s.Finger) > 0 {
-		return s.Finger[0]
+	if len(s.finger) > 0 {
+		return s.finger[0]
 	}
 	return nil
 }
@@ -221,12 +301,12 @@ func (s *ChordRpcServer) successor() *Node {
 func (s *ChordRpcServer) closestPrecedingFinger(id []byte) *Node {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
-	if len(s.Finger) == 0 {
+	if len(s.finger) == 0 {
 		return nil
 	}
-	for i := len(s.Finger) - 1; i >= 0; i-- {
-		if utils.IsInRangeExclude(s.Finger[i].Id, s.Self.Id, id) {
-			return s.Finger[i]
+	for i := len(s.finger) - 1; i >= 0; i-- {
+		if utils.IsInRangeExclude(s.finger[i].Id, s.Self.Id, id) {
+			return s.finger[i]
 		}
 	}
 	return s.Self
@@ -237,12 +317,12 @@ func (s *ChordRpcServer) GetInfoString() string {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	sb := strings.Builder{}
-	sb.WriteString(fmt.Sprintf("Predecessor: %v\n", s.Predecessor.ToString()))
+	sb.WriteString(fmt.Sprintf("Predecessor: %v\n", s.predecessor.ToString()))
 	sb.WriteString(fmt.Sprintf("Self: %v\n", s.Self.ToString()))
-	//fmt.Println("Predecessor:", s.Predecessor.ToString())
+	//fmt.Println("Predecessor:", s.predecessor.ToString())
 	sb.WriteString("fingers:")
 	//var indices, ids, ips []string
-	for i, node := range s.Finger {
+	for i, node := range s.finger {
 		if i > 2 {
 			break
 		}
@@ -269,6 +349,12 @@ func (s *ChordRpcServer) GetInfoString() string {
 		sb.WriteString(node.ToString() + ", ")
 	}
 	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("rtt: successor %v, predecessor %v\n", s.detector.Rtt(s.successor()), s.detector.Rtt(s.predecessor)))
+	sb.WriteString("predecessorList: ")
+	for _, node := range s.predecessorList {
+		sb.WriteString(node.ToString() + ", ")
+	}
+	sb.WriteString("\n")
 
 	//buf := bytes.NewBufferString("")
 	//w := tabwriter.NewWriter(buf, 1, 1, 1, ' ', 0)
@@ -294,32 +380,31 @@ func logFunc(name string, req interface{}, resp interface{}, err error) {
 func (s *ChordRpcServer) FindSuccessor(ctx context.Context, id *proto.Id) (resp *proto.Node, err error) {
 	defer logFunc("s.FindSuccessor", id, resp, err)
 	// if successor(s) is the successor of the id
-	if utils.IsInRange(id.Id, s.Self.Id, s.successor().Id) {
-		return s.successor().ToProtoNode(), nil
+	if suc := s.Successor(); utils.IsInRange(id.Id, s.Self.Id, suc.Id) {
+		return suc.ToProtoNode(), nil
 	}
 	// else
 	nn := s.closestPrecedingFinger(id.Id)
 	if nn == nil {
 		return nil, status.Errorf(codes.Unknown, "closestPrecedingFinger() is nil")
 	}
-	nnc, err := nn.GetClient(s.ClientCreds)
-	if err != nil {
-		return nil, err
-	}
-	res, err := nnc.FindSuccessor(ctx, id)
+	nnc, err := nn.GetClient(s.Pool)
 	if err != nil {
 		return nil, err
 	}
-	return &proto.Node{Id: res.Id, Addr: res.Addr}, nil
+	defer nn.Close()
+	// the successor may be a virtual node, so the reply is passed on as it is
+	return nnc.FindSuccessor(ctx, id)
 }
 
 // GetPredecessor returns our Predecessor.
 func (s *ChordRpcServer) GetPredecessor(ctx context.Context, in *proto.Void) (resp *proto.Node, err error) {
 	defer logFunc("s.GetPredecessor", in, resp, err)
-	if s.Predecessor == nil {
+	pred := s.Predecessor()
+	if pred == nil {
 		return nil, status.Errorf(codes.Unknown, "s.Predecessor is nil")
 	}
-	return s.Predecessor.ToProtoNode(), nil
+	return pred.ToProtoNode(), nil
 }
 
 /*=====================================================
@@ -329,82 +414,162 @@ func (s *ChordRpcServer) GetPredecessor(ctx context.Context, in *proto.Void) (re
 // Join the chord system through a broker.
 func (s *ChordRpcServer) Join(ctx context.Context, bootstrapper *Node) (err error) {
 	defer logFunc("s.Join", bootstrapper, nil, err)
-	s.Predecessor = nil
+	ctx, cancel := context.WithTimeout(ctx, s.Config().LookupTimeout)
+	defer cancel()
+	s.mutex.Lock()
+	s.predecessor = nil
+	s.predecessorList = nil
+	s.mutex.Unlock()
 
 	// ask bootstrapper for our successor
-	c, err := bootstrapper.GetClient(s.ClientCreds)
-	if err != nil {
-		return err
+	var suc *proto.Node
+	if s.Config().LookupMode == LOOKUP_ITERATIVE {
+		suc, err = s.iterate(ctx, s.Self.Id, []*proto.Node{bootstrapper.ToProtoNode()}, nil)
+	} else {
+		var c proto.ChordClient
+		c, err = bootstrapper.GetClient(s.Pool)
+		if err != nil {
+			return err
+		}
+		defer bootstrapper.Close()
+		suc, err = c.FindSuccessor(ctx, &proto.Id{Id: s.Self.Id})
 	}
-	suc, err := c.FindSuccessor(ctx, &proto.Id{Id: s.Self.Id})
 	if err != nil {
 		return err
 	}
-	s.Finger[0] = NewNodeFromProtoNode(suc)
+	s.mutex.Lock()
+	s.finger[0] = NewNodeFromProtoNode(suc)
+	s.mutex.Unlock()
 	return nil
 }
 
-// CheckPredecessorAndSuccessor checks whether the Predecessor and the successor are still alive.
+// CheckPredecessorAndSuccessor checks whether the Predecessor and the successor are still alive. A peer failing to
+// respond is only suspected, and an error is returned, until the failure detector declares it dead.
 func (s *ChordRpcServer) CheckPredecessorAndSuccessor(ctx context.Context) error {
-	c, err := s.successor().GetClient(s.ClientCreds)
-	if err == nil {
-		_, err = c.Ping(ctx, &proto.Void{})
-	}
+	defer func() {
+		// the peers replaced meanwhile are no longer watched
+		s.mutex.Lock()
+		suc, pred := s.successor(), s.predecessor
+		s.mutex.Unlock()
+		s.detector.Retain(suc, pred)
+	}()
+	suc := s.Successor()
+	sucAlive, sucErr := s.checkAlive(ctx, suc)
 	s.mutex.Lock()
-	if err != nil {
+	if !sucAlive && s.successor() == suc {
 		if len(s.successorList) >= 2 {
-			s.Finger[0] = s.successorList[1]
+			s.finger[0] = s.successorList[1]
 			s.successorList = s.successorList[1:]
 		} else {
-			s.Finger[0] = s.Self
+			s.finger[0] = s.Self
 		}
 	}
+	pred := s.predecessor
 	s.mutex.Unlock()
-	if s.Predecessor == nil {
-		return nil
+	if sucErr != nil {
+		sucErr = fmt.Errorf("successor %v suspected: %w", suc.Ident(), sucErr)
 	}
-	c, err = s.Predecessor.GetClient(s.ClientCreds)
-	if err == nil {
-		_, err = c.Ping(ctx, &proto.Void{})
+	if pred == nil {
+		return sucErr
 	}
-	if err != nil {
-		s.Predecessor = nil
+	predAlive, predErr := s.checkAlive(ctx, pred)
+	if !predAlive {
+		s.recoverPredecessor(ctx, pred)
+	} else if predErr == nil {
+		s.refreshPredecessorList(ctx, pred)
+	}
+	if sucErr != nil {
+		return sucErr
+	}
+	if predErr != nil {
+		return fmt.Errorf("predecessor %v suspected: %w", pred.Ident(), predErr)
 	}
 	return nil
 }
 
+// checkAlive pings the given node, and returns whether it is still considered alive by the failure detector,
+// together with the error of the ping, if any.
+func (s *ChordRpcServer) checkAlive(ctx context.Context, node *Node) (bool, error) {
+	start := time.Now()
+	err := s.ping(ctx, node)
+	if err == nil {
+		s.detector.Heartbeat(node, time.Since(start))
+		return true, nil
+	}
+	config := s.Config()
+	if phi := s.detector.Phi(node, config.CheckInterval); phi < config.PhiThreshold {
+		logger.Logger.Infow("s.checkAlive suspect node", "node", node, "phi", phi, "err", err)
+		return true, err
+	}
+	logger.Logger.Warnw("s.checkAlive node dead", "node", node, "err", err)
+	s.detector.Forget(node)
+	return false, err
+}
+
 // Stabilize periodically verify our immediate successor, and tell the successor about us.
 func (s *ChordRpcServer) Stabilize(ctx context.Context) (err error) {
 	defer logFunc("s.Stabilize", nil, nil, err)
-	c, err := s.successor().GetClient(s.ClientCreds)
+	ctx, cancel := context.WithTimeout(ctx, s.Config().PingTimeout)
+	defer cancel()
+	suc := s.Successor()
+	c, err := suc.GetClient(s.Pool)
 	if err != nil {
 		return err
 	}
+	defer suc.Close()
 	x, err := c.GetPredecessor(ctx, &proto.Void{})
-	if err == nil && utils.IsInRangeExclude(x.Id, s.Self.Id, s.successor().Id) {
-		if utils.CheckIdentity(x.Id, x.Addr) {
-			s.Finger[0] = NewNodeFromProtoNode(x)
+	if err == nil && utils.IsInRangeExclude(x.Id, s.Self.Id, suc.Id) {
+		if utils.CheckIdentity(x.Id, x.Addr, x.Vnode) {
+			s.mutex.Lock()
+			// the successor may have been replaced meanwhile
+			if s.successor() == suc {
+				s.finger[0] = NewNodeFromProtoNode(x)
+			}
+			s.mutex.Unlock()
 		} else {
 			logger.Logger.Warnw("Stabilize: identity check error", "node", x)
 		}
 	}
-	cSuc, err := s.successor().GetClient(s.ClientCreds)
+	newSuc := s.Successor()
+	cSuc, err := newSuc.GetClient(s.Pool)
 	if err != nil {
 		return err
 	}
+	defer newSuc.Close()
 	resp, err := cSuc.Notify(ctx, s.Self.ToProtoNode())
 	if err != nil {
 		return err
 	}
 	s.mutex.Lock()
-	s.successorList = []*Node{}
-	for _, node := range resp.GetNodes() {
-		s.successorList = append(s.successorList, NewNodeFromProtoNode(node))
-	}
+	s.successorList = successorList(s.Self, resp.GetNodes(), s.Config().SuccessorListSize)
 	s.mutex.Unlock()
 	return nil
 }
 
+// successorList returns our successor list taken from the given successor list of our successor, prepended with the
+// successor itself. The list is sized in servers rather than virtual nodes, i.e. it spans the given number of servers
+// other than ours, so that the replicas forwarded along it land on as many servers even if consecutive successors are
+// virtual nodes of the same server. It ends early once it wraps around the ring to us.
+func successorList(self *Node, nodes []*proto.Node, servers int) []*Node {
+	list := []*Node{}
+	seen := make(map[string]bool)
+	for _, node := range nodes {
+		// the successor may keep a successor list of a different size
+		if len(seen) >= servers {
+			break
+		}
+		n := NewNodeFromProtoNode(node)
+		list = append(list, n)
+		if n.Ident() == self.Ident() {
+			break
+		}
+		if n.Addr != self.Addr {
+			seen[n.Addr] = true
+		}
+	}
+	return list
+}
+
 // Notify lets us think `nn` might be our Predecessor.
 func (s *ChordRpcServer) Notify(ctx context.Context, nn *proto.Node) (resp *proto.SuccessorList, err error) {
 	defer logFunc("s.Notify", nn, resp, err)
@@ -412,35 +577,74 @@ func (s *ChordRpcServer) Notify(ctx context.Context, nn *proto.Node) (resp *prot
 	defer s.mutex.Unlock()
 
 	// verify node, to avoid ID mapping attacking
-	if !utils.CheckIdentity(nn.Id, nn.Addr) {
+	if !utils.CheckIdentity(nn.Id, nn.Addr, nn.Vnode) {
 		logger.Logger.Warnw("Notify: identity check error", "node", nn)
 		return nil, status.Error(codes.PermissionDenied, "identity check error")
 	}
 
-	if s.Predecessor == nil || utils.IsInRangeExclude(nn.Id, s.Predecessor.Id, s.Self.Id) {
-		s.Predecessor = NewNodeFromProtoNode(nn)
+	if s.predecessor == nil || utils.IsInRangeExclude(nn.Id, s.predecessor.Id, s.Self.Id) {
+		s.predecessor = NewNodeFromProtoNode(nn)
+		s.predecessorList = append([]*Node{s.predecessor}, s.predecessorList...)
+		if len(s.predecessorList) > s.Config().SuccessorListSize {
+			s.predecessorList = s.predecessorList[:s.Config().SuccessorListSize]
+		}
 		// no need to transfer data
+		// a new predecessor joins, so that speed up the maintenance tasks
+		s.ring.broadcast()
 	}
+	// return our whole successor list, so that the predecessor can take as many successors as its list size
 	resp = &proto.SuccessorList{Nodes: []*proto.Node{s.Self.ToProtoNode()}}
 	for _, node := range s.successorList {
-		if len(resp.Nodes) >= NUM_SUCCESSORS_IN_LIST {
-			break
-		}
 		resp.Nodes = append(resp.Nodes, node.ToProtoNode())
 	}
 	return resp, nil
 }
 
-// FixFingers refreshes a random Finger table entry, should be called periodically.
+// FixFingers refreshes the Finger table by the configured FingerRefresh strategy, should be called periodically.
 func (s *ChordRpcServer) FixFingers(ctx context.Context) (err error) {
 	defer logFunc("s.FixFingers", nil, nil, err)
+	if s.Config().FingerRefresh == FINGER_REFRESH_RANDOM {
+		return s.fixRandomFinger(ctx)
+	}
+	return s.fixAllFingers(ctx)
+}
+
+// fixRandomFinger refreshes a random Finger table entry.
+func (s *ChordRpcServer) fixRandomFinger(ctx context.Context) error {
 	i := rand.Intn(M-1) + 1 // random integer number in [1,M)
 	id := utils.AddBytesPower2(s.Self.Id, i)
-	node, err := s.FindSuccessor(ctx, &proto.Id{Id: id})
+	node, err := s.Lookup(ctx, id)
 	if err != nil {
 		return err
 	}
-	s.Finger[i] = NewNodeFromProtoNode(node)
+	s.mutex.Lock()
+	s.finger[i] = NewNodeFromProtoNode(node)
+	s.mutex.Unlock()
+	return nil
+}
+
+// fixAllFingers refreshes the whole Finger table walking the entries in order. The node found for an entry is also
+// the successor of all the following entries whose start falls before it, so that only O(log N) lookups are needed.
+func (s *ChordRpcServer) fixAllFingers(ctx context.Context) error {
+	successor := s.Successor()
+	for i := 1; i < M; {
+		start := utils.AddBytesPower2(s.Self.Id, i)
+		node := successor
+		if !utils.IsInRange(start, s.Self.Id, successor.Id) {
+			found, err := s.Lookup(ctx, start)
+			if err != nil {
+				return err
+			}
+			node = NewNodeFromProtoNode(found)
+		}
+		s.mutex.Lock()
+		s.finger[i] = node
+		// the following entries whose start falls in (Self, node] share the same successor
+		for i++; i < M && utils.IsInRange(utils.AddBytesPower2(s.Self.Id, i), s.Self.Id, node.Id); i++ {
+			s.finger[i] = node
+		}
+		s.mutex.Unlock()
+	}
 	return nil
 }
 
@@ -453,33 +657,48 @@ func (s *ChordRpcServer) Ping(ctx context.Context, in *proto.Void) (*proto.Void,
                  Storage Operations
 =====================================================*/
 
-// Put asks us to put the key/value pair to our storage, then forwards the request to our successor if needed.
-func (s *ChordRpcServer) Put(ctx context.Context, req *proto.PutReq) (resp *proto.Void, err error) {
-	defer logFunc("s.Put", req, resp, err)
+// store puts the key/value pair of the PutReq to our storage, and returns the PutReq to forward to our successor, if needed.
+func (s *ChordRpcServer) store(req *proto.PutReq) *proto.PutReq {
 	if req.GetInitiatorAddr() == "" {
 		req.InitiatorAddr = s.Self.Addr
 	} else if s.Self.Addr == req.GetInitiatorAddr() {
-		return &proto.Void{}, nil
+		return nil
 	}
 	ttl := time.UnixMilli(req.Expire).Sub(time.Now())
 	if ttl.Milliseconds() > 0 {
-		s.storage.Put(req.Key, req.Value, ttl)
+		// a new version is assigned if we are responsible for the key, i.e. req.Version is 0
+		req.Version = s.storage.PutReplica(req.Key, req.Value, ttl, req.Version, req.Replication)
 	}
-	// forward the request to successor
 	if req.Replication <= 1 {
-		return &proto.Void{}, nil
-	}
-	c, err := s.Finger[0].GetClient(s.ClientCreds)
-	if err != nil {
-		return nil, err
+		return nil
 	}
-	_, err = c.Put(ctx, &proto.PutReq{
+	return &proto.PutReq{
 		Key:           req.Key,
 		Value:         req.Value,
 		Expire:        req.Expire,
 		InitiatorAddr: req.InitiatorAddr,
 		Replication:   req.Replication - 1,
-	})
+		Version:       req.Version,
+	}
+}
+
+// Put asks us to put the key/value pair to our storage, then forwards the request to our successor if needed.
+func (s *ChordRpcServer) Put(ctx context.Context, req *proto.PutReq) (resp *proto.Void, err error) {
+	defer logFunc("s.Put", req, resp, err)
+	forward := s.store(req)
+	// forward the request to successor
+	next := s.replicaSuccessor()
+	if forward == nil || next == nil {
+		return &proto.Void{}, nil
+	}
+	c, err := next.GetClient(s.Pool)
+	if err != nil {
+		return nil, err
+	}
+	defer next.Close()
+	ctx, cancel := context.WithTimeout(ctx, s.Config().PutTimeout)
+	defer cancel()
+	_, err = c.Put(ctx, forward)
 	if err != nil {
 		return nil, err
 	}
@@ -492,3 +711,154 @@ func (s *ChordRpcServer) Get(ctx context.Context, req *proto.GetReq) (resp *prot
 	val, ok := s.storage.Get(req.GetKey())
 	return &proto.GetResp{Value: val, Ok: ok}, nil
 }
+
+// BatchPut asks us to put all the key/value pairs to our storage, then forwards the request to our successor if needed.
+func (s *ChordRpcServer) BatchPut(ctx context.Context, req *proto.BatchPutReq) (resp *proto.Void, err error) {
+	defer logFunc("s.BatchPut", req, resp, err)
+	forward := &proto.BatchPutReq{}
+	for _, r := range req.GetReqs() {
+		if f := s.store(r); f != nil {
+			forward.Reqs = append(forward.Reqs, f)
+		}
+	}
+	// forward the request to successor
+	next := s.replicaSuccessor()
+	if len(forward.Reqs) == 0 || next == nil {
+		return &proto.Void{}, nil
+	}
+	c, err := next.GetClient(s.Pool)
+	if err != nil {
+		return nil, err
+	}
+	defer next.Close()
+	ctx, cancel := context.WithTimeout(ctx, s.Config().PutTimeout)
+	defer cancel()
+	_, err = c.BatchPut(ctx, forward)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.Void{}, nil
+}
+
+// BatchGet asks us to get the values for all the given keys from our storage.
+func (s *ChordRpcServer) BatchGet(ctx context.Context, req *proto.BatchGetReq) (resp *proto.BatchGetResp, err error) {
+	defer logFunc("s.BatchGet", req, resp, err)
+	resp = &proto.BatchGetResp{}
+	for _, key := range req.GetKeys() {
+		val, ok := s.storage.Get(key)
+		resp.Resps = append(resp.Resps, &proto.GetResp{Value: val, Ok: ok})
+	}
+	return resp, nil
+}
+
+// CondPut asks us to put the key/value pair to our storage only if the condition holds, then forwards the request to our successor if needed.
+func (s *ChordRpcServer) CondPut(ctx context.Context, req *proto.CondPutReq) (resp *proto.CondPutResp, err error) {
+	defer logFunc("s.CondPut", req, resp, err)
+	r := req.GetReq()
+	if r == nil {
+		return nil, status.Error(codes.InvalidArgument, "req is nil")
+	}
+	ttl := time.UnixMilli(r.Expire).Sub(time.Now())
+	if ttl.Milliseconds() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "req has expired")
+	}
+	version, value, ok := s.storage.PutIf(r.Key, r.Value, ttl, r.Replication, storage.Condition{
+		Kind:    storage.CondKind(req.Condition),
+		Value:   req.ExpectedValue,
+		Version: req.ExpectedVersion,
+	})
+	resp = &proto.CondPutResp{Ok: ok, Version: version, Value: value}
+	// forward the request to successor only if the condition holds
+	next := s.replicaSuccessor()
+	if !ok || r.Replication <= 1 || next == nil {
+		return resp, nil
+	}
+	c, err := next.GetClient(s.Pool)
+	if err != nil {
+		return nil, err
+	}
+	defer next.Close()
+	ctx, cancel := context.WithTimeout(ctx, s.Config().PutTimeout)
+	defer cancel()
+	_, err = c.Put(ctx, &proto.PutReq{
+		Key:           r.Key,
+		Value:         r.Value,
+		Expire:        r.Expire,
+		InitiatorAddr: s.Self.Addr,
+		Replication:   r.Replication - 1,
+		Version:       version,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Touch asks us to extend the expiry of the given key in our storage, then forwards the request to our successor if needed.
+func (s *ChordRpcServer) Touch(ctx context.Context, req *proto.TouchReq) (resp *proto.TouchResp, err error) {
+	defer logFunc("s.Touch", req, resp, err)
+	if req.GetInitiatorAddr() == "" {
+		req.InitiatorAddr = s.Self.Addr
+	} else if s.Self.Addr == req.GetInitiatorAddr() {
+		return &proto.TouchResp{}, nil
+	}
+	ttl := time.UnixMilli(req.Expire).Sub(time.Now())
+	if ttl.Milliseconds() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "req has expired")
+	}
+	ok := s.storage.Touch(req.Key, ttl)
+	// forward the request to successor
+	next := s.replicaSuccessor()
+	if req.Replication <= 1 || next == nil {
+		return &proto.TouchResp{Ok: ok}, nil
+	}
+	c, err := next.GetClient(s.Pool)
+	if err != nil {
+		return nil, err
+	}
+	defer next.Close()
+	ctx, cancel := context.WithTimeout(ctx, s.Config().PutTimeout)
+	defer cancel()
+	_, err = c.Touch(ctx, &proto.TouchReq{
+		Key:           req.Key,
+		Expire:        req.Expire,
+		InitiatorAddr: req.InitiatorAddr,
+		Replication:   req.Replication - 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &proto.TouchResp{Ok: ok}, nil
+}
+
+// Delete asks us to delete the given key from our storage, then forwards the request to our successor if needed.
+func (s *ChordRpcServer) Delete(ctx context.Context, req *proto.DeleteReq) (resp *proto.DeleteResp, err error) {
+	defer logFunc("s.Delete", req, resp, err)
+	if req.GetInitiatorAddr() == "" {
+		req.InitiatorAddr = s.Self.Addr
+	} else if s.Self.Addr == req.GetInitiatorAddr() {
+		return &proto.DeleteResp{}, nil
+	}
+	ok := s.storage.Delete(req.Key)
+	// forward the request to successor
+	next := s.replicaSuccessor()
+	if req.Replication <= 1 || next == nil {
+		return &proto.DeleteResp{Ok: ok}, nil
+	}
+	c, err := next.GetClient(s.Pool)
+	if err != nil {
+		return nil, err
+	}
+	defer next.Close()
+	ctx, cancel := context.WithTimeout(ctx, s.Config().PutTimeout)
+	defer cancel()
+	_, err = c.Delete(ctx, &proto.DeleteReq{
+		Key:           req.Key,
+		InitiatorAddr: req.InitiatorAddr,
+		Replication:   req.Replication - 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &proto.DeleteResp{Ok: ok}, nil
+}
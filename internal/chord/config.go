@@ -0,0 +1,133 @@
+package chord
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Here defines the default deadlines of the requests.
+const (
+	DEFAULT_LOOKUP_TIMEOUT = 5 * time.Second // default deadline of each lookup
+	DEFAULT_PUT_TIMEOUT    = 5 * time.Second // default deadline of each put
+	DEFAULT_GET_TIMEOUT    = 5 * time.Second // default deadline of each get
+)
+
+// LookupMode defines how the lookups initiated by us are performed.
+type LookupMode int
+
+// Here defines some LookupMode constants for all modes of lookups.
+const (
+	LOOKUP_RECURSIVE LookupMode = 0 // each hop asks the next hop to find the successor on behalf of us
+	LOOKUP_ITERATIVE LookupMode = 1 // we ask each hop for the next hop, and drive the lookup ourselves
+)
+
+// ParseLookupMode parses a LookupMode from its name, i.e. "recursive" or "iterative".
+func ParseLookupMode(s string) (LookupMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "recursive":
+		return LOOKUP_RECURSIVE, nil
+	case "iterative":
+		return LOOKUP_ITERATIVE, nil
+	}
+	return 0, fmt.Errorf("unknown lookup mode %q, should be recursive or iterative", s)
+}
+
+// String returns the name of the LookupMode.
+func (m LookupMode) String() string {
+	if m == LOOKUP_ITERATIVE {
+		return "iterative"
+	}
+	return "recursive"
+}
+
+// FingerRefresh defines how the Finger table is refreshed by FixFingers.
+type FingerRefresh int
+
+// Here defines some FingerRefresh constants for all strategies of refreshing fingers.
+const (
+	FINGER_REFRESH_FULL   FingerRefresh = 0 // refresh the whole table in order, reusing the successor found for following entries
+	FINGER_REFRESH_RANDOM FingerRefresh = 1 // refresh a single random entry
+)
+
+// ParseFingerRefresh parses a FingerRefresh from its name, i.e. "full" or "random".
+func ParseFingerRefresh(s string) (FingerRefresh, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "full":
+		return FINGER_REFRESH_FULL, nil
+	case "random":
+		return FINGER_REFRESH_RANDOM, nil
+	}
+	return 0, fmt.Errorf("unknown finger refresh strategy %q, should be full or random", s)
+}
+
+// String returns the name of the FingerRefresh.
+func (r FingerRefresh) String() string {
+	if r == FINGER_REFRESH_RANDOM {
+		return "random"
+	}
+	return "full"
+}
+
+// Config defines the parameters of the Chord algorithm run by a P2pServer.
+type Config struct {
+	LookupMode         LookupMode    // the mode of the lookups initiated by us
+	VirtualNodes       int           // the number of virtual nodes run by the P2pServer, which should be proportional to its capacity
+	SuccessorListSize  int           // the number of other servers spanned by our successor list, which may hold more virtual nodes
+	FingerRefresh      FingerRefresh // the strategy of refreshing the Finger table
+	CheckInterval      time.Duration // the min interval of checking the Predecessor and the successor
+	StabilizeInterval  time.Duration // the min interval of stabilizing
+	FixFingersInterval time.Duration // the min interval of fixing fingers
+	PhiThreshold       float64       // the suspicion level of the failure detector above which a peer is declared dead
+	PingTimeout        time.Duration // the timeout of each ping checking whether a peer is alive
+	MaxInterval        time.Duration // the max interval which the maintenance tasks back off to while the ring is stable, no backoff if not larger than the min intervals
+	Jitter             float64       // the max fraction by which the intervals are randomly shifted
+	LookupTimeout      time.Duration // the deadline of each lookup, shared by all of its hops
+	PutTimeout         time.Duration // the deadline of each put, including the lookup and the forwarding to the replicas
+	GetTimeout         time.Duration // the deadline of each get, including the lookup
+	JoinRetryInterval  time.Duration // the interval before retrying to join after all the bootstrappers fail, doubling on each failure
+	JoinMaxInterval    time.Duration // the max interval which the retries of joining back off to
+	JoinTimeout        time.Duration // the time spent on joining at startup before giving up
+	RetryJoin          bool          // whether to run as a lone ring rather than fail once JoinTimeout is exceeded, retrying to join in background while alone
+}
+
+// withDefaults returns the Config with the defaults filled in for the unset fields.
+func (c Config) withDefaults() Config {
+	if c.SuccessorListSize <= 0 {
+		c.SuccessorListSize = DEFAULT_SUCCESSOR_LIST_SIZE
+	}
+	if c.PhiThreshold <= 0 {
+		c.PhiThreshold = DEFAULT_PHI_THRESHOLD
+	}
+	if c.PingTimeout <= 0 {
+		c.PingTimeout = DEFAULT_PING_TIMEOUT
+	}
+	if c.LookupTimeout <= 0 {
+		c.LookupTimeout = DEFAULT_LOOKUP_TIMEOUT
+	}
+	if c.PutTimeout <= 0 {
+		c.PutTimeout = DEFAULT_PUT_TIMEOUT
+	}
+	if c.GetTimeout <= 0 {
+		c.GetTimeout = DEFAULT_GET_TIMEOUT
+	}
+	if c.CheckInterval <= 0 {
+		c.CheckInterval = DEFAULT_CHECK_INTERVAL
+	}
+	if c.StabilizeInterval <= 0 {
+		c.StabilizeInterval = DEFAULT_STABILIZE_INTERVAL
+	}
+	if c.FixFingersInterval <= 0 {
+		c.FixFingersInterval = DEFAULT_FIX_FINGERS_INTERVAL
+	}
+	if c.JoinRetryInterval <= 0 {
+		c.JoinRetryInterval = DEFAULT_JOIN_RETRY_INTERVAL
+	}
+	if c.JoinMaxInterval <= 0 {
+		c.JoinMaxInterval = DEFAULT_JOIN_MAX_INTERVAL
+	}
+	if c.JoinTimeout <= 0 {
+		c.JoinTimeout = DEFAULT_JOIN_TIMEOUT
+	}
+	return c
+}
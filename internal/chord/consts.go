@@ -1,4 +1,6 @@
 package chord
 
-const M = 160                    // #bits of ids
-const NUM_SUCCESSORS_IN_LIST = 3 // max number of successors in the successor list
+import "crypto/sha1"
+
+const M = sha1.Size * 8               // #bits of ids, tied to the hash function of ids, i.e. utils.SHA1
+const DEFAULT_SUCCESSOR_LIST_SIZE = 3 // default max number of successors in the successor list
@@ -0,0 +1,137 @@
+package chord
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const PHI_WINDOW_SIZE = 100                          // max number of heartbeat intervals kept for each peer
+const PHI_MIN_STD_DEVIATION = 100 * time.Millisecond // min standard deviation of heartbeat intervals, to tolerate jitter
+const RTT_SMOOTHING = 0.125                          // the weight of a new sample in the smoothed round-trip time
+
+// Here defines the default parameters of the failure detector.
+const (
+	DEFAULT_PHI_THRESHOLD = 8.0         // default suspicion level above which a peer is declared dead
+	DEFAULT_PING_TIMEOUT  = time.Second // default timeout of each ping
+)
+
+// heartbeatHistory defines the history of the heartbeats from a peer, i.e. our successful pings to it.
+type heartbeatHistory struct {
+	last      time.Time     // the time of the last heartbeat, or when the peer is first seen
+	intervals []float64     // the recent intervals between heartbeats, in milliseconds
+	rtt       time.Duration // the smoothed round-trip time of the pings
+}
+
+// FailureDetector defines a phi-accrual failure detector, which computes a suspicion level of each peer from the
+// distribution of its heartbeat intervals, instead of evicting a peer on a single failed ping.
+type FailureDetector struct {
+	mutex sync.Mutex
+	peers map[string]*heartbeatHistory // the histories of the peers, keyed by Node.Ident
+}
+
+// history returns the heartbeat history of the given node, which is created if the node is first seen.
+func (d *FailureDetector) history(node *Node) *heartbeatHistory {
+	if d.peers == nil {
+		d.peers = make(map[string]*heartbeatHistory)
+	}
+	h, ok := d.peers[node.Ident()]
+	if !ok {
+		h = &heartbeatHistory{last: time.Now()}
+		d.peers[node.Ident()] = h
+	}
+	return h
+}
+
+// Heartbeat records a successful ping to the given node, taking `rtt`.
+func (d *FailureDetector) Heartbeat(node *Node, rtt time.Duration) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	h := d.history(node)
+	now := time.Now()
+	h.intervals = append(h.intervals, float64(now.Sub(h.last).Milliseconds()))
+	if len(h.intervals) > PHI_WINDOW_SIZE {
+		h.intervals = h.intervals[1:]
+	}
+	h.last = now
+	if h.rtt == 0 {
+		h.rtt = rtt
+	} else {
+		h.rtt += time.Duration(RTT_SMOOTHING * float64(rtt-h.rtt))
+	}
+}
+
+// Phi returns the suspicion level of the given node, i.e. -log10 of the probability that a heartbeat arrives even
+// later than now. The given interval is assumed as the mean interval before enough heartbeats are seen.
+func (d *FailureDetector) Phi(node *Node, interval time.Duration) float64 {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	h := d.history(node)
+	mean, std := float64(interval.Milliseconds()), 0.0
+	if len(h.intervals) >= 2 {
+		mean = 0
+		for _, v := range h.intervals {
+			mean += v
+		}
+		mean /= float64(len(h.intervals))
+		for _, v := range h.intervals {
+			std += (v - mean) * (v - mean)
+		}
+		std = math.Sqrt(std / float64(len(h.intervals)))
+	}
+	std = math.Max(std, float64(PHI_MIN_STD_DEVIATION.Milliseconds()))
+
+	// the logistic approximation of the cumulative distribution function of the normal distribution
+	elapsed := float64(time.Since(h.last).Milliseconds())
+	y := (elapsed - mean) / std
+	e := math.Exp(-y * (1.5976 + 0.070566*y*y))
+	if elapsed > mean {
+		return -math.Log10(e / (1 + e))
+	}
+	return -math.Log10(1 - 1/(1+e))
+}
+
+// Rtt returns the smoothed round-trip time of the pings to the given node, 0 if unknown.
+func (d *FailureDetector) Rtt(node *Node) time.Duration {
+	if node == nil {
+		return 0
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if h, ok := d.peers[node.Ident()]; ok {
+		return h.rtt
+	}
+	return 0
+}
+
+// Forget drops the history of the given node, once it is declared dead.
+func (d *FailureDetector) Forget(node *Node) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	delete(d.peers, node.Ident())
+}
+
+// Retain drops the histories of all the nodes except the given ones, i.e. the peers no longer watched, so that the
+// histories do not pile up as the successor and the Predecessor change.
+func (d *FailureDetector) Retain(nodes ...*Node) {
+	keep := make(map[string]bool)
+	for _, node := range nodes {
+		if node != nil {
+			keep[node.Ident()] = true
+		}
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for ident := range d.peers {
+		if !keep[ident] {
+			delete(d.peers, ident)
+		}
+	}
+}
+
+// Len returns the number of the peers whose histories are kept.
+func (d *FailureDetector) Len() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return len(d.peers)
+}
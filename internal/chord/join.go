@@ -0,0 +1,181 @@
+package chord
+
+import (
+	"DHT/internal/logger"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Here defines the defaults of joining the existing Chord network.
+const (
+	DEFAULT_JOIN_RETRY_INTERVAL = time.Second      // default interval before retrying after all the bootstrappers fail
+	DEFAULT_JOIN_MAX_INTERVAL   = 30 * time.Second // default max interval which the retries back off to
+	DEFAULT_JOIN_TIMEOUT        = time.Minute      // default time spent on joining at startup before giving up
+)
+
+// Discoverer defines a source of the nodes of the Chord network, e.g. found on the local network, which are used as
+// the bootstrappers if none is specified.
+type Discoverer interface {
+	// Wait blocks until any node is discovered, or the existing nodes should have been, and returns the nodes discovered.
+	Wait(ctx context.Context) []string
+	// Discover returns the P2P addresses of the nodes discovered so far.
+	Discover() []string
+}
+
+// join lets all our virtual nodes join the existing Chord network through the first bootstrapper answering, or the
+// Chord network created by the virtual node 0 if no bootstrapper is specified or discovered. The bootstrappers are
+// retried with backoff for JoinTimeout. If none answers by then, it fails, or runs as a lone ring if RetryJoin is set,
+// in which case we keep retrying in background whenever we are alone. We always keep retrying the nodes discovered,
+// since they may have gone, and the nodes started at the same time may each create a ring before discovering each other.
+func (s *P2pServer) join(ctx context.Context, configured []string) error {
+	config := s.RpcServer.Config()
+	bootstrappers := func() []string {
+		return configured
+	}
+	initial, retry := configured, config.RetryJoin && len(configured) > 0
+	if len(configured) == 0 && s.Discoverer != nil {
+		bootstrappers, retry = s.Discoverer.Discover, true
+		initial = s.Discoverer.Wait(ctx)
+		logger.Logger.Infow("discovered bootstrappers", "bootstrappers", initial)
+	}
+	// the other virtual nodes join the chord created by the virtual node 0 by default
+	bootstrapper, joined := s.RpcServer.Self.Addr, true
+	if len(initial) > 0 {
+		atomic.StoreInt32(&s.expectPeers, 1)
+		joinCtx, cancel := context.WithTimeout(ctx, config.JoinTimeout)
+		answered, err := s.joinWithBackoff(joinCtx, bootstrappers)
+		cancel()
+		if ctx.Err() != nil {
+			return nil
+		}
+		switch {
+		case err == nil:
+			logger.Logger.Debugw("server.Join ok", "bootstrapper", answered)
+			bootstrapper = answered
+		case !retry:
+			return fmt.Errorf("server.Join error: %w", err)
+		default:
+			logger.Logger.Warnw("running as a lone ring, retrying to join in background", "bootstrappers", initial, "err", err)
+			// the lone ring is a fallback, we have not joined until a bootstrapper answers
+			joined = false
+		}
+	}
+	if err := s.joinVirtualNodes(ctx, bootstrapper); err != nil {
+		return err
+	}
+	if joined {
+		atomic.StoreInt32(&s.joined, 1)
+	}
+	if retry {
+		s.wg.Add(1)
+		go s.rejoin(ctx, bootstrappers)
+	}
+	return nil
+}
+
+// joinVirtualNodes lets our virtual nodes other than 0 join the Chord network through the given bootstrapper.
+func (s *P2pServer) joinVirtualNodes(ctx context.Context, bootstrapper string) error {
+	for _, server := range s.VirtualServers[1:] {
+		if err := server.Join(ctx, NewNode(bootstrapper)); err != nil {
+			return fmt.Errorf("server.Join error: %w", err)
+		}
+	}
+	return nil
+}
+
+// joinAny lets the virtual node 0 join the Chord network through the bootstrappers tried in order, and returns the
+// first one answering.
+func (s *P2pServer) joinAny(ctx context.Context, bootstrappers []string) (string, error) {
+	if len(bootstrappers) == 0 {
+		return "", errors.New("no bootstrapper known")
+	}
+	var errs []string
+	for _, bootstrapper := range bootstrappers {
+		err := s.RpcServer.Join(ctx, NewNode(bootstrapper))
+		if err == nil {
+			return bootstrapper, nil
+		}
+		logger.Logger.Infow("server.Join bootstrapper failed", "bootstrapper", bootstrapper, "err", err)
+		errs = append(errs, bootstrapper+": "+err.Error())
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return "", fmt.Errorf("no bootstrapper answered: %s", strings.Join(errs, "; "))
+}
+
+// joinWithBackoff tries all the current bootstrappers in rounds until one answers or the context is done, and returns
+// the one answering. The interval between the rounds starts at JoinRetryInterval, and doubles up to JoinMaxInterval.
+func (s *P2pServer) joinWithBackoff(ctx context.Context, bootstrappers func() []string) (string, error) {
+	interval := s.RpcServer.Config().JoinRetryInterval
+	for {
+		bootstrapper, err := s.joinAny(ctx, bootstrappers())
+		if err == nil {
+			return bootstrapper, nil
+		}
+		config := s.RpcServer.Config()
+		select {
+		case <-ctx.Done():
+			return "", err
+		case <-time.After(jitter(interval, config.Jitter)):
+		}
+		interval = backoff(interval, config.JoinMaxInterval)
+	}
+}
+
+// rejoin tries to join the Chord network through the current bootstrappers whenever we are running as a lone ring, e.g. when
+// we are started before the bootstrappers, or have lost all the other nodes, until the context is canceled. The
+// retries back off from JoinRetryInterval to JoinMaxInterval, which is also the interval of checking whether we
+// are alone.
+func (s *P2pServer) rejoin(ctx context.Context, bootstrappers func() []string) {
+	defer s.wg.Done()
+	interval := s.RpcServer.Config().JoinRetryInterval
+	for {
+		config := s.RpcServer.Config()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(jitter(interval, config.Jitter)):
+		}
+		if !s.alone() {
+			// e.g. another node has joined us meanwhile
+			atomic.StoreInt32(&s.joined, 1)
+			interval = config.JoinMaxInterval
+			continue
+		}
+		bootstrapper, err := s.joinAny(ctx, bootstrappers())
+		if err != nil {
+			interval = backoff(interval, config.JoinMaxInterval)
+			continue
+		}
+		logger.Logger.Infow("joined the network in background", "bootstrapper", bootstrapper)
+		if err := s.joinVirtualNodes(ctx, bootstrapper); err != nil {
+			logger.Logger.Warnw("server.Join virtual nodes error", "bootstrapper", bootstrapper, "err", err)
+		}
+		atomic.StoreInt32(&s.joined, 1)
+		interval = config.JoinMaxInterval
+	}
+}
+
+// alone returns whether our virtual nodes only know each other, i.e. we are running as a lone ring.
+func (s *P2pServer) alone() bool {
+	for _, server := range s.VirtualServers {
+		if server.Successor().Addr != server.Self.Addr {
+			return false
+		}
+	}
+	return true
+}
+
+// backoff returns the next interval of retrying, doubled from the given one up to the max interval.
+func backoff(interval time.Duration, maxInterval time.Duration) time.Duration {
+	interval *= BACKOFF_FACTOR
+	if interval > maxInterval {
+		interval = maxInterval
+	}
+	return interval
+}
@@ -0,0 +1,162 @@
+package chord
+
+import (
+	"DHT/internal/chord/proto"
+	"DHT/internal/logger"
+	"DHT/internal/utils"
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+const NUM_LOOKUP_CANDIDATES = 3 // max number of nodes returned by ClosestPrecedingFinger, besides our successor list
+
+// Lookup finds the given id's successor in the mode configured, which should be used for any lookups initiated by us.
+func (s *ChordRpcServer) Lookup(ctx context.Context, id []byte) (*proto.Node, error) {
+	trace, err := s.LookupTrace(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return trace.GetSuccessor(), nil
+}
+
+// LookupTrace finds the given id's successor in the mode configured, and returns the hops visited as well.
+// The lookup is bounded by the configured LookupTimeout, or the deadline of the given context if earlier, which is
+// propagated through the hops of a recursive lookup, so that each hop only has the remaining budget.
+func (s *ChordRpcServer) LookupTrace(ctx context.Context, id []byte) (trace *proto.Trace, err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.Config().LookupTimeout)
+	defer cancel()
+	if s.Config().LookupMode == LOOKUP_ITERATIVE {
+		trace = &proto.Trace{}
+		step := s.lookupStep(id)
+		if step.Found {
+			trace.Successor = step.Successor
+		} else {
+			trace.Successor, err = s.iterate(ctx, id, step.Nodes, trace)
+		}
+	} else {
+		trace, err = s.TraceSuccessor(ctx, &proto.Id{Id: id})
+	}
+	hops := 0
+	for _, hop := range trace.GetHops() {
+		if !hop.GetFailed() {
+			hops++
+		}
+	}
+	s.stats.recordLookup(hops, err)
+	s.metrics.ObserveLookup(hops, err)
+	if err != nil {
+		return nil, err
+	}
+	return trace, nil
+}
+
+// lookupStep returns our successor if it is the successor of the given id, otherwise returns the nodes closer to the id,
+// the closest first.
+func (s *ChordRpcServer) lookupStep(id []byte) *proto.LookupStep {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if utils.IsInRange(id, s.Self.Id, s.successor().Id) {
+		return &proto.LookupStep{Found: true, Successor: s.successor().ToProtoNode()}
+	}
+	step := &proto.LookupStep{}
+	added := map[string]bool{s.Self.Ident(): true}
+	add := func(node *Node) {
+		if node != nil && !added[node.Ident()] && utils.IsInRangeExclude(node.Id, s.Self.Id, id) {
+			added[node.Ident()] = true
+			step.Nodes = append(step.Nodes, node.ToProtoNode())
+		}
+	}
+	for i := len(s.finger) - 1; i >= 0 && len(step.Nodes) < NUM_LOOKUP_CANDIDATES; i-- {
+		add(s.finger[i])
+	}
+	// our successors are the fallbacks if all the fingers are unresponsive
+	for i := len(s.successorList) - 1; i >= 0; i-- {
+		add(s.successorList[i])
+	}
+	return step
+}
+
+// iterate drives an iterative lookup of the given id's successor, starting from the given candidates, the closest first.
+// An unresponsive candidate is skipped, and the next closest candidate is asked instead. The hops visited are appended
+// to the trace, if not nil. Each hop is bounded by the configured PingTimeout.
+func (s *ChordRpcServer) iterate(ctx context.Context, id []byte, candidates []*proto.Node, trace *proto.Trace) (*proto.Node, error) {
+	visited := map[string]bool{s.Self.Ident(): true}
+	for hops := 0; hops < M; {
+		if len(candidates) == 0 {
+			return nil, status.Error(codes.Unavailable, "no responsive node for the lookup")
+		}
+		next := candidates[0]
+		candidates = candidates[1:]
+		node := NewNodeFromProtoNode(next)
+		if visited[node.Ident()] {
+			continue
+		}
+		visited[node.Ident()] = true
+		c, err := node.GetClient(s.Pool)
+		if err != nil {
+			logger.Logger.Infow("s.iterate skip node", "node", next, "err", err)
+			continue
+		}
+		// an unresponsive hop should not exhaust the budget of the whole lookup
+		hopCtx, cancel := context.WithTimeout(ctx, s.Config().PingTimeout)
+		start := time.Now()
+		step, err := c.ClosestPrecedingFinger(hopCtx, &proto.Id{Id: id})
+		cancel()
+		node.Close()
+		if trace != nil {
+			trace.Hops = append(trace.Hops, &proto.Hop{Node: next, Latency: time.Since(start).Microseconds(), Failed: err != nil})
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			logger.Logger.Infow("s.iterate skip node", "node", next, "err", err)
+			continue
+		}
+		hops++
+		if step.GetFound() {
+			return step.GetSuccessor(), nil
+		}
+		candidates = append(step.GetNodes(), candidates...)
+	}
+	return nil, status.Error(codes.Unknown, "too many hops for the lookup")
+}
+
+// ClosestPrecedingFinger asks us for one step of an iterative lookup of the given id's successor.
+func (s *ChordRpcServer) ClosestPrecedingFinger(ctx context.Context, id *proto.Id) (resp *proto.LookupStep, err error) {
+	defer logFunc("s.ClosestPrecedingFinger", id, resp, err)
+	return s.lookupStep(id.GetId()), nil
+}
+
+// TraceSuccessor asks us to find the given id's successor as FindSuccessor does, and return the hops visited.
+// The latency of each hop excludes the time spent by the hops after it.
+func (s *ChordRpcServer) TraceSuccessor(ctx context.Context, id *proto.Id) (resp *proto.Trace, err error) {
+	defer logFunc("s.TraceSuccessor", id, resp, err)
+	// if successor(s) is the successor of the id
+	if suc := s.Successor(); utils.IsInRange(id.Id, s.Self.Id, suc.Id) {
+		return &proto.Trace{Successor: suc.ToProtoNode()}, nil
+	}
+	// else
+	nn := s.closestPrecedingFinger(id.Id)
+	if nn == nil {
+		return nil, status.Errorf(codes.Unknown, "closestPrecedingFinger() is nil")
+	}
+	nnc, err := nn.GetClient(s.Pool)
+	if err != nil {
+		return nil, err
+	}
+	defer nn.Close()
+	start := time.Now()
+	res, err := nnc.TraceSuccessor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	latency := time.Since(start).Microseconds()
+	for _, hop := range res.GetHops() {
+		latency -= hop.GetLatency()
+	}
+	hops := append([]*proto.Hop{{Node: nn.ToProtoNode(), Latency: latency}}, res.GetHops()...)
+	return &proto.Trace{Successor: res.GetSuccessor(), Hops: hops}, nil
+}
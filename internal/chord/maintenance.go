@@ -0,0 +1,161 @@
+package chord
+
+import (
+	"DHT/internal/logger"
+	"context"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+const BACKOFF_FACTOR = 2                // the factor by which the interval of a stable maintenance task grows
+const STABLE_ROUNDS_BEFORE_BACKOFF = 10 // number of rounds without changes before a maintenance task backs off
+
+// Here defines the default intervals of the maintenance tasks.
+const (
+	DEFAULT_CHECK_INTERVAL       = 100 * time.Millisecond // default interval of CheckPredecessorAndSuccessor
+	DEFAULT_STABILIZE_INTERVAL   = 100 * time.Millisecond // default interval of Stabilize
+	DEFAULT_FIX_FINGERS_INTERVAL = 100 * time.Millisecond // default interval of FixFingers
+)
+
+// task defines a maintenance task run periodically by a P2pServer. Its intervals are read from the Config of the
+// virtual node in each round, so that they can be changed while running.
+type task struct {
+	name         string                            // the name of the task, used for logging
+	run          func(ctx context.Context) error   // runs the task once
+	interval     func(config Config) time.Duration // returns the interval while the ring is changing
+	stableRounds func(config Config) int           // returns the number of rounds without changes before the task backs off
+}
+
+// ringSignal detects the changes of our view of the ring, and wakes up the maintenance tasks on changes.
+type ringSignal struct {
+	mutex     sync.Mutex
+	signature string        // the signature of our last view of the ring
+	changed   chan struct{} // closed when our view of the ring changes
+}
+
+// wait returns a channel which is closed when our view of the ring changes.
+func (r *ringSignal) wait() <-chan struct{} {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.changed == nil {
+		r.changed = make(chan struct{})
+	}
+	return r.changed
+}
+
+// broadcast wakes up all the maintenance tasks waiting for changes.
+func (r *ringSignal) broadcast() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.changed != nil {
+		close(r.changed)
+	}
+	r.changed = make(chan struct{})
+}
+
+// update records the given signature of our view of the ring, and returns whether it has changed.
+func (r *ringSignal) update(signature string) bool {
+	r.mutex.Lock()
+	changed := r.signature != signature
+	r.signature = signature
+	r.mutex.Unlock()
+	if changed {
+		r.broadcast()
+	}
+	return changed
+}
+
+// ringSignature returns a string identifying our current view of the ring, i.e. the predecessors, the successors and the Finger table.
+func (s *ChordRpcServer) ringSignature() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	sb := strings.Builder{}
+	if s.predecessor != nil {
+		sb.WriteString(s.predecessor.Ident())
+	}
+	for _, node := range s.predecessorList {
+		sb.WriteString("<" + node.Ident())
+	}
+	for _, node := range s.successorList {
+		sb.WriteString("," + node.Ident())
+	}
+	for _, node := range s.finger {
+		sb.WriteString(";" + node.Ident())
+	}
+	return sb.String()
+}
+
+// checkRing checks whether our view of the ring has changed, and wakes up the maintenance tasks if so.
+func (s *ChordRpcServer) checkRing() bool {
+	if !s.ring.update(s.ringSignature()) {
+		return false
+	}
+	logger.Logger.Debugw("ring changed", "info", s.GetInfoString())
+	return true
+}
+
+// jitter randomly shifts the interval by at most the given fraction, so that the tasks of nodes don't run in lockstep.
+func jitter(interval time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return interval
+	}
+	return interval + time.Duration((rand.Float64()*2-1)*fraction*float64(interval))
+}
+
+// tasks returns the maintenance tasks of the given virtual node of the P2pServer.
+func (s *P2pServer) tasks(server *ChordRpcServer) []*task {
+	stableRounds := func(Config) int {
+		return STABLE_ROUNDS_BEFORE_BACKOFF
+	}
+	fixFingersRounds := func(config Config) int {
+		if config.FingerRefresh == FINGER_REFRESH_RANDOM {
+			// only one random finger is refreshed in each round, so wait for about a whole table of rounds
+			return M
+		}
+		return STABLE_ROUNDS_BEFORE_BACKOFF
+	}
+	return []*task{
+		{"CheckPredecessorAndSuccessor", server.CheckPredecessorAndSuccessor, func(config Config) time.Duration { return config.CheckInterval }, stableRounds},
+		{"Stabilize", server.Stabilize, func(config Config) time.Duration { return config.StabilizeInterval }, stableRounds},
+		{"FixFingers", server.FixFingers, func(config Config) time.Duration { return config.FixFingersInterval }, fixFingersRounds},
+	}
+}
+
+// runTask runs the maintenance task of the given virtual node periodically until the context is canceled. The interval doubles after the task
+// has seen no changes of the ring for a number of rounds, and falls back to the min interval once the ring or the Config changes.
+func (s *P2pServer) runTask(ctx context.Context, server *ChordRpcServer, t *task) {
+	defer s.wg.Done()
+	interval, stable := t.interval(server.Config()), 0
+	for {
+		changed := server.ring.wait()
+		timer := time.NewTimer(jitter(interval, server.Config().Jitter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-changed:
+			timer.Stop()
+			interval, stable = t.interval(server.Config()), 0
+		case <-timer.C:
+		}
+
+		err := t.run(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			logger.Logger.Warnw("server."+t.name+" error", "err", err)
+			server.metrics.MaintenanceError(t.name)
+		}
+		config := server.Config()
+		if server.checkRing() || err != nil {
+			interval, stable = t.interval(config), 0
+			continue
+		}
+		if stable++; stable >= t.stableRounds(config) && interval < config.MaxInterval {
+			interval, stable = backoff(interval, config.MaxInterval), 0
+		}
+	}
+}
@@ -4,42 +4,82 @@ import (
 	"DHT/internal/chord/proto"
 	"DHT/internal/utils"
 	"bytes"
+	"context"
 	"encoding/hex"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"strconv"
+	"sync"
 )
 
+const VNODE_METADATA_KEY = "vnode" // the key of the grpc metadata telling which virtual node a request is sent to
+
 // Node defines a chord node, which can be used for communication
 type Node struct {
 	Id         []byte            // the id of this node
 	Addr       string            // the address of format ip:p2p_port
-	clientConn *grpc.ClientConn  // the grpc.ClientConn connecting to this node
+	Vnode      int32             // the index of this virtual node among the ones of the server at Addr
+	mutex      sync.Mutex        // guards the connection, since a node in the ring state is shared by the goroutines
+	pool       *ConnPool         // the ConnPool which the connection to this node is acquired from
+	clientConn *pooledConn       // the connection to this node, shared with the other Nodes of the same address
 	client     proto.ChordClient // the proto.ChordClient, used for sending any requests
+	refs       int               // the number of GetClient calls not closed yet, holding the connection
 }
 
-// GetClient returns the proto.ChordClient for sending any requests to this node
-func (p *Node) GetClient(clientCreds credentials.TransportCredentials) (proto.ChordClient, error) {
-	if p.client != nil {
-		return p.client, nil
-	}
-	conn, err := grpc.Dial(p.Addr, grpc.WithTransportCredentials(clientCreds))
-	if err != nil {
-		return nil, err
-	}
-	c := proto.NewChordClient(conn)
-	p.client = c
-	p.clientConn = conn
+// vnodeConn defines a grpc.ClientConnInterface sending all requests to the given virtual node of the server.
+type vnodeConn struct {
+	*grpc.ClientConn
+	vnode int32 // the index of the virtual node
+}
+
+// Invoke sends the unary request to the virtual node.
+func (c *vnodeConn) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+	ctx = metadata.AppendToOutgoingContext(ctx, VNODE_METADATA_KEY, strconv.Itoa(int(c.vnode)))
+	return c.ClientConn.Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream sends the streaming request to the virtual node.
+func (c *vnodeConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	ctx = metadata.AppendToOutgoingContext(ctx, VNODE_METADATA_KEY, strconv.Itoa(int(c.vnode)))
+	return c.ClientConn.NewStream(ctx, desc, method, opts...)
+}
 
-	//runtime.SetFinalizer(p, p.Close)
+// GetClient returns the proto.ChordClient for sending any requests to this node, over the connection acquired from
+// the given ConnPool. Each call should be paired with a Close once the client is no longer used, and the connection
+// is released by the last one, since a node in the ring state may be used by several goroutines at once.
+func (p *Node) GetClient(pool *ConnPool) (proto.ChordClient, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.client == nil {
+		conn, err := pool.acquire(p.Addr)
+		if err != nil {
+			return nil, err
+		}
+		if p.Vnode == 0 {
+			p.client = proto.NewChordClient(conn.conn)
+		} else {
+			p.client = proto.NewChordClient(&vnodeConn{ClientConn: conn.conn, vnode: p.Vnode})
+		}
+		p.clientConn = conn
+		p.pool = pool
+	}
+	p.refs++
 	return p.client, nil
 }
 
-// Close closes the connection to this node
+// Close closes a client returned by GetClient, and releases the connection to this node once all of them are closed.
 func (p *Node) Close() {
-	if p.clientConn != nil {
-		p.clientConn.Close()
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.refs == 0 {
+		return
+	}
+	p.refs--
+	if p.refs == 0 {
+		p.pool.release(p.clientConn)
 		p.clientConn = nil
 		p.client = nil
+		p.pool = nil
 	}
 }
 
@@ -51,31 +91,48 @@ func (p *Node) ToString() string {
 	sb := bytes.NewBufferString("(")
 	sb.WriteString(hex.EncodeToString(p.Id))
 	sb.WriteString(", ")
-	sb.WriteString(p.Addr)
+	sb.WriteString(p.Ident())
 	sb.WriteString(")")
 	return sb.String()
 }
 
+// Ident returns a string identifying this virtual node among all the nodes, i.e. the address followed by the index
+// of the virtual node if it is not 0.
+func (p *Node) Ident() string {
+	if p.Vnode == 0 {
+		return p.Addr
+	}
+	return p.Addr + "#" + strconv.Itoa(int(p.Vnode))
+}
+
 // ToProtoNode creates a proto.Node of this node
 func (p *Node) ToProtoNode() *proto.Node {
 	return &proto.Node{
-		Id:   p.Id,
-		Addr: p.Addr,
+		Id:    p.Id,
+		Addr:  p.Addr,
+		Vnode: p.Vnode,
 	}
 }
 
 // NewNodeFromProtoNode creates a Node from the given proto.Node
 func NewNodeFromProtoNode(p *proto.Node) *Node {
 	return &Node{
-		Id:   p.Id,
-		Addr: p.Addr,
+		Id:    p.Id,
+		Addr:  p.Addr,
+		Vnode: p.Vnode,
 	}
 }
 
 // NewNode creates a Node from the given address
 func NewNode(addr string) *Node {
+	return NewVirtualNode(addr, 0)
+}
+
+// NewVirtualNode creates a Node of the given virtual node of the server at the address
+func NewVirtualNode(addr string, vnode int32) *Node {
 	return &Node{
-		Id:   utils.SHA1([]byte(addr)),
-		Addr: addr,
+		Id:    utils.NodeId(addr, vnode),
+		Addr:  addr,
+		Vnode: vnode,
 	}
 }
@@ -0,0 +1,130 @@
+package chord
+
+import (
+	"DHT/internal/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"sync"
+	"time"
+)
+
+// Here defines the parameters of the ConnPool.
+const (
+	CONN_IDLE_TIMEOUT   = time.Minute      // how long a connection no longer referenced is kept before closed
+	CONN_SWEEP_INTERVAL = 10 * time.Second // the interval of evicting idle and unhealthy connections
+)
+
+// pooledConn defines a connection in the ConnPool, shared by all the Nodes of the same address.
+type pooledConn struct {
+	addr     string           // the address connected to
+	conn     *grpc.ClientConn // the underlying connection
+	refs     int              // the number of Nodes holding this connection
+	lastUsed time.Time        // the time when the connection is last acquired or released
+	stale    bool             // whether the connection is replaced by a new one after found unhealthy
+}
+
+// healthy returns whether the connection is usable, or may become usable by reconnecting.
+func (c *pooledConn) healthy() bool {
+	state := c.conn.GetState()
+	return state != connectivity.TransientFailure && state != connectivity.Shutdown
+}
+
+// ConnPool defines a pool of the connections to the other P2P servers, keyed by address, so that all the Nodes of the
+// same address share one connection, instead of each dialing a new one. A connection is reference counted by the Nodes
+// holding it, and closed once it is no longer referenced for CONN_IDLE_TIMEOUT, or is unhealthy.
+type ConnPool struct {
+	mutex sync.Mutex
+	creds credentials.TransportCredentials // the credentials of the connections
+	conns map[string]*pooledConn           // the current connections, keyed by address
+}
+
+// NewConnPool creates a ConnPool dialing with the given credentials.
+func NewConnPool(creds credentials.TransportCredentials) *ConnPool {
+	return &ConnPool{
+		creds: creds,
+		conns: make(map[string]*pooledConn),
+	}
+}
+
+// acquire returns the connection to the given address, which should be released after use. An unhealthy connection is
+// replaced by a new one, while the holders of the old one keep it until released.
+func (p *ConnPool) acquire(addr string) (*pooledConn, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	c, ok := p.conns[addr]
+	if ok && !c.healthy() {
+		logger.Logger.Infow("ConnPool replace unhealthy connection", "addr", addr, "state", c.conn.GetState())
+		c.stale = true
+		delete(p.conns, addr)
+		if c.refs == 0 {
+			c.conn.Close()
+		}
+		ok = false
+	}
+	if !ok {
+		conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(p.creds))
+		if err != nil {
+			return nil, err
+		}
+		c = &pooledConn{addr: addr, conn: conn}
+		p.conns[addr] = c
+	}
+	c.refs++
+	c.lastUsed = time.Now()
+	return c, nil
+}
+
+// release drops a reference to the given connection, which is closed at once if it has been replaced.
+func (p *ConnPool) release(c *pooledConn) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	c.refs--
+	c.lastUsed = time.Now()
+	if c.refs <= 0 && c.stale {
+		c.conn.Close()
+	}
+}
+
+// evict closes the connections no longer referenced, which are idle for CONN_IDLE_TIMEOUT or unhealthy.
+func (p *ConnPool) evict() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for addr, c := range p.conns {
+		if c.refs > 0 {
+			continue
+		}
+		if time.Since(c.lastUsed) >= CONN_IDLE_TIMEOUT || !c.healthy() {
+			delete(p.conns, addr)
+			c.conn.Close()
+		}
+	}
+}
+
+// Len returns the number of the connections in the pool.
+func (p *ConnPool) Len() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.conns)
+}
+
+// Refs returns the number of the references held on the connections in the pool.
+func (p *ConnPool) Refs() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	refs := 0
+	for _, c := range p.conns {
+		refs += c.refs
+	}
+	return refs
+}
+
+// Close closes all the connections in the pool.
+func (p *ConnPool) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for addr, c := range p.conns {
+		delete(p.conns, addr)
+		c.conn.Close()
+	}
+}
@@ -0,0 +1,150 @@
+package chord
+
+import (
+	"DHT/internal/chord/proto"
+	"DHT/internal/logger"
+	"DHT/internal/utils"
+	"context"
+	"time"
+)
+
+// ping checks whether the given node is alive, within the configured PingTimeout.
+func (s *ChordRpcServer) ping(ctx context.Context, node *Node) error {
+	c, err := node.GetClient(s.Pool)
+	if err != nil {
+		return err
+	}
+	defer node.Close()
+	ctx, cancel := context.WithTimeout(ctx, s.Config().PingTimeout)
+	defer cancel()
+	_, err = c.Ping(ctx, &proto.Void{})
+	return err
+}
+
+// GetPredecessorList returns our predecessor list, the closest first.
+func (s *ChordRpcServer) GetPredecessorList(ctx context.Context, in *proto.Void) (resp *proto.PredecessorList, err error) {
+	defer logFunc("s.GetPredecessorList", in, resp, err)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	resp = &proto.PredecessorList{}
+	for _, node := range s.predecessorList {
+		resp.Nodes = append(resp.Nodes, node.ToProtoNode())
+	}
+	return resp, nil
+}
+
+// refreshPredecessorList rebuilds our predecessor list from the predecessor list of our alive Predecessor `pred`,
+// symmetric to the successor list taken from our successor.
+func (s *ChordRpcServer) refreshPredecessorList(ctx context.Context, pred *Node) {
+	c, err := pred.GetClient(s.Pool)
+	if err != nil {
+		return
+	}
+	defer pred.Close()
+	ctx, cancel := context.WithTimeout(ctx, s.Config().PingTimeout)
+	defer cancel()
+	resp, err := c.GetPredecessorList(ctx, &proto.Void{})
+	if err != nil {
+		logger.Logger.Infow("s.refreshPredecessorList error", "node", pred, "err", err)
+		return
+	}
+	list := []*Node{pred}
+	for _, node := range resp.GetNodes() {
+		// stop once the list wraps around the ring to us
+		if len(list) >= s.Config().SuccessorListSize || NewNodeFromProtoNode(node).Ident() == s.Self.Ident() {
+			break
+		}
+		list = append(list, NewNodeFromProtoNode(node))
+	}
+	s.mutex.Lock()
+	if s.predecessor == pred {
+		s.predecessorList = list
+	}
+	s.mutex.Unlock()
+}
+
+// recoverPredecessor adopts the next alive node in our predecessor list as our Predecessor, after the Predecessor
+// `failed` is found dead, and takes the responsibility for the keys of the dead predecessors.
+func (s *ChordRpcServer) recoverPredecessor(ctx context.Context, failed *Node) {
+	s.mutex.Lock()
+	candidates := s.predecessorList
+	s.mutex.Unlock()
+
+	dead := []*Node{failed}
+	var alive *Node
+	var rest []*Node
+	for i, node := range candidates {
+		if node.Ident() == failed.Ident() || node.Ident() == s.Self.Ident() {
+			continue
+		}
+		if s.ping(ctx, node) == nil {
+			alive, rest = node, candidates[i:]
+			break
+		}
+		dead = append(dead, node)
+	}
+
+	s.mutex.Lock()
+	if s.predecessor != failed {
+		// someone has notified us meanwhile
+		s.mutex.Unlock()
+		return
+	}
+	s.predecessor, s.predecessorList = alive, rest
+	s.mutex.Unlock()
+	s.ring.broadcast()
+	logger.Logger.Infow("s.recoverPredecessor", "failed", failed, "adopted", alive)
+	if alive != nil {
+		s.takeOver(ctx, alive, dead)
+	}
+}
+
+// takeOver takes the responsibility for the keys of the dead predecessors, the closest first, which are in the range
+// (pred, dead[0]]. We hold the replicas of these keys, and restore the replicas lost with the dead predecessors by
+// forwarding them to our successors.
+func (s *ChordRpcServer) takeOver(ctx context.Context, pred *Node, dead []*Node) {
+	forward := &proto.BatchPutReq{}
+	for _, item := range s.storage.Items() {
+		id := utils.SHA1(item.Key)
+		if item.Replication <= 0 || !utils.IsInRange(id, pred.Id, dead[0].Id) {
+			continue
+		}
+		// the replicas lost are on the dead predecessors from the one responsible for the key to us
+		lost := len(dead)
+		for i := range dead {
+			lower := pred.Id
+			if i+1 < len(dead) {
+				lower = dead[i+1].Id
+			}
+			if utils.IsInRange(id, lower, dead[i].Id) {
+				lost = i + 1
+				break
+			}
+		}
+		f := s.store(&proto.PutReq{
+			Key:         item.Key,
+			Value:       item.Value,
+			Expire:      time.Now().Add(item.TTL).UnixMilli(),
+			Replication: item.Replication + int32(lost),
+			Version:     item.Version,
+		})
+		if f != nil {
+			forward.Reqs = append(forward.Reqs, f)
+		}
+	}
+	next := s.replicaSuccessor()
+	if len(forward.Reqs) == 0 || next == nil {
+		return
+	}
+	logger.Logger.Infow("s.takeOver", "n", len(forward.Reqs), "successor", next)
+	ctx, cancel := context.WithTimeout(ctx, s.Config().PutTimeout)
+	defer cancel()
+	c, err := next.GetClient(s.Pool)
+	if err == nil {
+		_, err = c.BatchPut(ctx, forward)
+		next.Close()
+	}
+	if err != nil {
+		logger.Logger.Warnw("s.takeOver error", "successor", next, "err", err)
+	}
+}
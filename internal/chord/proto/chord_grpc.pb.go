@@ -23,11 +23,20 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ChordClient interface {
 	FindSuccessor(ctx context.Context, in *Id, opts ...grpc.CallOption) (*Node, error)
+	TraceSuccessor(ctx context.Context, in *Id, opts ...grpc.CallOption) (*Trace, error)
+	ClosestPrecedingFinger(ctx context.Context, in *Id, opts ...grpc.CallOption) (*LookupStep, error)
 	Notify(ctx context.Context, in *Node, opts ...grpc.CallOption) (*SuccessorList, error)
 	GetPredecessor(ctx context.Context, in *Void, opts ...grpc.CallOption) (*Node, error)
+	GetPredecessorList(ctx context.Context, in *Void, opts ...grpc.CallOption) (*PredecessorList, error)
 	Ping(ctx context.Context, in *Void, opts ...grpc.CallOption) (*Void, error)
 	Put(ctx context.Context, in *PutReq, opts ...grpc.CallOption) (*Void, error)
 	Get(ctx context.Context, in *GetReq, opts ...grpc.CallOption) (*GetResp, error)
+	BatchPut(ctx context.Context, in *BatchPutReq, opts ...grpc.CallOption) (*Void, error)
+	BatchGet(ctx context.Context, in *BatchGetReq, opts ...grpc.CallOption) (*BatchGetResp, error)
+	CondPut(ctx context.Context, in *CondPutReq, opts ...grpc.CallOption) (*CondPutResp, error)
+	Watch(ctx context.Context, in *WatchReq, opts ...grpc.CallOption) (Chord_WatchClient, error)
+	Touch(ctx context.Context, in *TouchReq, opts ...grpc.CallOption) (*TouchResp, error)
+	Delete(ctx context.Context, in *DeleteReq, opts ...grpc.CallOption) (*DeleteResp, error)
 }
 
 type chordClient struct {
@@ -47,6 +56,24 @@ func (c *chordClient) FindSuccessor(ctx context.Context, in *Id, opts ...grpc.Ca
 	return out, nil
 }
 
+func (c *chordClient) TraceSuccessor(ctx context.Context, in *Id, opts ...grpc.CallOption) (*Trace, error) {
+	out := new(Trace)
+	err := c.cc.Invoke(ctx, "/proto.Chord/TraceSuccessor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) ClosestPrecedingFinger(ctx context.Context, in *Id, opts ...grpc.CallOption) (*LookupStep, error) {
+	out := new(LookupStep)
+	err := c.cc.Invoke(ctx, "/proto.Chord/ClosestPrecedingFinger", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *chordClient) Notify(ctx context.Context, in *Node, opts ...grpc.CallOption) (*SuccessorList, error) {
 	out := new(SuccessorList)
 	err := c.cc.Invoke(ctx, "/proto.Chord/Notify", in, out, opts...)
@@ -65,6 +92,15 @@ func (c *chordClient) GetPredecessor(ctx context.Context, in *Void, opts ...grpc
 	return out, nil
 }
 
+func (c *chordClient) GetPredecessorList(ctx context.Context, in *Void, opts ...grpc.CallOption) (*PredecessorList, error) {
+	out := new(PredecessorList)
+	err := c.cc.Invoke(ctx, "/proto.Chord/GetPredecessorList", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *chordClient) Ping(ctx context.Context, in *Void, opts ...grpc.CallOption) (*Void, error) {
 	out := new(Void)
 	err := c.cc.Invoke(ctx, "/proto.Chord/Ping", in, out, opts...)
@@ -92,16 +128,102 @@ func (c *chordClient) Get(ctx context.Context, in *GetReq, opts ...grpc.CallOpti
 	return out, nil
 }
 
+func (c *chordClient) BatchPut(ctx context.Context, in *BatchPutReq, opts ...grpc.CallOption) (*Void, error) {
+	out := new(Void)
+	err := c.cc.Invoke(ctx, "/proto.Chord/BatchPut", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) BatchGet(ctx context.Context, in *BatchGetReq, opts ...grpc.CallOption) (*BatchGetResp, error) {
+	out := new(BatchGetResp)
+	err := c.cc.Invoke(ctx, "/proto.Chord/BatchGet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) CondPut(ctx context.Context, in *CondPutReq, opts ...grpc.CallOption) (*CondPutResp, error) {
+	out := new(CondPutResp)
+	err := c.cc.Invoke(ctx, "/proto.Chord/CondPut", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) Watch(ctx context.Context, in *WatchReq, opts ...grpc.CallOption) (Chord_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Chord_ServiceDesc.Streams[0], "/proto.Chord/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chordWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Chord_WatchClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type chordWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *chordWatchClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chordClient) Touch(ctx context.Context, in *TouchReq, opts ...grpc.CallOption) (*TouchResp, error) {
+	out := new(TouchResp)
+	err := c.cc.Invoke(ctx, "/proto.Chord/Touch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) Delete(ctx context.Context, in *DeleteReq, opts ...grpc.CallOption) (*DeleteResp, error) {
+	out := new(DeleteResp)
+	err := c.cc.Invoke(ctx, "/proto.Chord/Delete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ChordServer is the server API for Chord service.
 // All implementations must embed UnimplementedChordServer
 // for forward compatibility
 type ChordServer interface {
 	FindSuccessor(context.Context, *Id) (*Node, error)
+	TraceSuccessor(context.Context, *Id) (*Trace, error)
+	ClosestPrecedingFinger(context.Context, *Id) (*LookupStep, error)
 	Notify(context.Context, *Node) (*SuccessorList, error)
 	GetPredecessor(context.Context, *Void) (*Node, error)
+	GetPredecessorList(context.Context, *Void) (*PredecessorList, error)
 	Ping(context.Context, *Void) (*Void, error)
 	Put(context.Context, *PutReq) (*Void, error)
 	Get(context.Context, *GetReq) (*GetResp, error)
+	BatchPut(context.Context, *BatchPutReq) (*Void, error)
+	BatchGet(context.Context, *BatchGetReq) (*BatchGetResp, error)
+	CondPut(context.Context, *CondPutReq) (*CondPutResp, error)
+	Watch(*WatchReq, Chord_WatchServer) error
+	Touch(context.Context, *TouchReq) (*TouchResp, error)
+	Delete(context.Context, *DeleteReq) (*DeleteResp, error)
 	mustEmbedUnimplementedChordServer()
 }
 
@@ -112,12 +234,21 @@ type UnimplementedChordServer struct {
 func (UnimplementedChordServer) FindSuccessor(context.Context, *Id) (*Node, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method FindSuccessor not implemented")
 }
+func (UnimplementedChordServer) TraceSuccessor(context.Context, *Id) (*Trace, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TraceSuccessor not implemented")
+}
+func (UnimplementedChordServer) ClosestPrecedingFinger(context.Context, *Id) (*LookupStep, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClosestPrecedingFinger not implemented")
+}
 func (UnimplementedChordServer) Notify(context.Context, *Node) (*SuccessorList, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Notify not implemented")
 }
 func (UnimplementedChordServer) GetPredecessor(context.Context, *Void) (*Node, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetPredecessor not implemented")
 }
+func (UnimplementedChordServer) GetPredecessorList(context.Context, *Void) (*PredecessorList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPredecessorList not implemented")
+}
 func (UnimplementedChordServer) Ping(context.Context, *Void) (*Void, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
 }
@@ -127,6 +258,24 @@ func (UnimplementedChordServer) Put(context.Context, *PutReq) (*Void, error) {
 func (UnimplementedChordServer) Get(context.Context, *GetReq) (*GetResp, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
 }
+func (UnimplementedChordServer) BatchPut(context.Context, *BatchPutReq) (*Void, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchPut not implemented")
+}
+func (UnimplementedChordServer) BatchGet(context.Context, *BatchGetReq) (*BatchGetResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGet not implemented")
+}
+func (UnimplementedChordServer) CondPut(context.Context, *CondPutReq) (*CondPutResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CondPut not implemented")
+}
+func (UnimplementedChordServer) Watch(*WatchReq, Chord_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedChordServer) Touch(context.Context, *TouchReq) (*TouchResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Touch not implemented")
+}
+func (UnimplementedChordServer) Delete(context.Context, *DeleteReq) (*DeleteResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
 func (UnimplementedChordServer) mustEmbedUnimplementedChordServer() {}
 
 // UnsafeChordServer may be embedded to opt out of forward compatibility for this service.
@@ -158,6 +307,42 @@ func _Chord_FindSuccessor_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Chord_TraceSuccessor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Id)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).TraceSuccessor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Chord/TraceSuccessor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).TraceSuccessor(ctx, req.(*Id))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_ClosestPrecedingFinger_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Id)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).ClosestPrecedingFinger(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Chord/ClosestPrecedingFinger",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).ClosestPrecedingFinger(ctx, req.(*Id))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Chord_Notify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Node)
 	if err := dec(in); err != nil {
@@ -194,6 +379,24 @@ func _Chord_GetPredecessor_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Chord_GetPredecessorList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Void)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).GetPredecessorList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Chord/GetPredecessorList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).GetPredecessorList(ctx, req.(*Void))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Chord_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Void)
 	if err := dec(in); err != nil {
@@ -248,6 +451,117 @@ func _Chord_Get_Handler(srv interface{}, ctx context.Context, dec func(interface
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Chord_BatchPut_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchPutReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).BatchPut(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Chord/BatchPut",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).BatchPut(ctx, req.(*BatchPutReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_BatchGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).BatchGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Chord/BatchGet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).BatchGet(ctx, req.(*BatchGetReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_CondPut_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CondPutReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).CondPut(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Chord/CondPut",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).CondPut(ctx, req.(*CondPutReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchReq)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChordServer).Watch(m, &chordWatchServer{stream})
+}
+
+type Chord_WatchServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type chordWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *chordWatchServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Chord_Touch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TouchReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).Touch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Chord/Touch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).Touch(ctx, req.(*TouchReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Chord/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).Delete(ctx, req.(*DeleteReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Chord_ServiceDesc is the grpc.ServiceDesc for Chord service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -259,6 +573,14 @@ var Chord_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "FindSuccessor",
 			Handler:    _Chord_FindSuccessor_Handler,
 		},
+		{
+			MethodName: "TraceSuccessor",
+			Handler:    _Chord_TraceSuccessor_Handler,
+		},
+		{
+			MethodName: "ClosestPrecedingFinger",
+			Handler:    _Chord_ClosestPrecedingFinger_Handler,
+		},
 		{
 			MethodName: "Notify",
 			Handler:    _Chord_Notify_Handler,
@@ -267,6 +589,10 @@ var Chord_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetPredecessor",
 			Handler:    _Chord_GetPredecessor_Handler,
 		},
+		{
+			MethodName: "GetPredecessorList",
+			Handler:    _Chord_GetPredecessorList_Handler,
+		},
 		{
 			MethodName: "Ping",
 			Handler:    _Chord_Ping_Handler,
@@ -279,7 +605,33 @@ var Chord_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Get",
 			Handler:    _Chord_Get_Handler,
 		},
+		{
+			MethodName: "BatchPut",
+			Handler:    _Chord_BatchPut_Handler,
+		},
+		{
+			MethodName: "BatchGet",
+			Handler:    _Chord_BatchGet_Handler,
+		},
+		{
+			MethodName: "CondPut",
+			Handler:    _Chord_CondPut_Handler,
+		},
+		{
+			MethodName: "Touch",
+			Handler:    _Chord_Touch_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _Chord_Delete_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Chord_Watch_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "chord.proto",
 }
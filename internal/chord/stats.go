@@ -0,0 +1,74 @@
+package chord
+
+import (
+	"math/big"
+	"sync"
+)
+
+const NUM_HOP_BUCKETS = 17 // #buckets of the hop-count histogram, the last bucket counts the lookups of at least 16 hops
+
+// LookupStats defines the statistics of the lookups initiated by us.
+type LookupStats struct {
+	Lookups   uint64   `json:"lookups"`    // the number of lookups
+	Failures  uint64   `json:"failures"`   // the number of failed lookups
+	HopCounts []uint64 `json:"hop_counts"` // the histogram of hop counts of succeeded lookups, HopCounts[i] lookups took i hops
+}
+
+// stats defines the statistics of a ChordRpcServer, safe for concurrent use.
+type stats struct {
+	lookups LookupStats
+	mutex   sync.Mutex
+}
+
+// recordLookup records a lookup taking the given number of hops.
+func (st *stats) recordLookup(hops int, err error) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	if st.lookups.HopCounts == nil {
+		st.lookups.HopCounts = make([]uint64, NUM_HOP_BUCKETS)
+	}
+	st.lookups.Lookups++
+	if err != nil {
+		st.lookups.Failures++
+		return
+	}
+	if hops >= NUM_HOP_BUCKETS {
+		hops = NUM_HOP_BUCKETS - 1
+	}
+	st.lookups.HopCounts[hops]++
+}
+
+// LookupStats returns a copy of the statistics of the lookups initiated by us.
+func (s *ChordRpcServer) LookupStats() LookupStats {
+	s.stats.mutex.Lock()
+	defer s.stats.mutex.Unlock()
+	res := s.stats.lookups
+	res.HopCounts = make([]uint64, NUM_HOP_BUCKETS)
+	copy(res.HopCounts, s.stats.lookups.HopCounts)
+	return res
+}
+
+// RingSizeEstimate estimates the number of the nodes in the ring, counting the virtual nodes, from the density of our
+// successor list, i.e. the number of successors divided by the fraction of the identifier space they span. It is
+// exact if the successor list wraps around the ring.
+func (s *ChordRpcServer) RingSizeEstimate() float64 {
+	s.mutex.Lock()
+	successors := append([]*Node(nil), s.successorList...)
+	s.mutex.Unlock()
+	for i, node := range successors {
+		if node.Ident() == s.Self.Ident() {
+			return float64(i + 1)
+		}
+	}
+	if len(successors) == 0 {
+		return 1
+	}
+	space := new(big.Int).Lsh(big.NewInt(1), M)
+	span := new(big.Int).Sub(new(big.Int).SetBytes(successors[len(successors)-1].Id), new(big.Int).SetBytes(s.Self.Id))
+	span.Mod(span, space)
+	if span.Sign() == 0 {
+		return 1
+	}
+	density, _ := new(big.Float).Quo(new(big.Float).SetInt(space), new(big.Float).SetInt(span)).Float64()
+	return float64(len(successors)) * density
+}
@@ -0,0 +1,102 @@
+package chord
+
+import (
+	"DHT/internal/utils"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// NodeStatus defines a node in the Status.
+type NodeStatus struct {
+	Id    string `json:"id"`    // the hex id of the node
+	Addr  string `json:"addr"`  // the P2P address of the node
+	Vnode int32  `json:"vnode"` // the index of the virtual node
+}
+
+// FingerStatus defines an entry of the finger table in the Status.
+type FingerStatus struct {
+	Start string      `json:"start"` // the hex id of Self.Id + 2^i, whose successor the entry should be
+	Node  *NodeStatus `json:"node"`  // the node of the entry, nil if not set yet
+}
+
+// Status defines the ring state of a ChordRpcServer, i.e. what GetInfoString prints, in a form which can be marshaled
+// to JSON.
+type Status struct {
+	Self            NodeStatus     `json:"self"`
+	Predecessor     *NodeStatus    `json:"predecessor"` // nil if unknown
+	Fingers         []FingerStatus `json:"fingers"`     // the full finger table, Fingers[i] for Self.Id + 2^i
+	SuccessorList   []NodeStatus   `json:"successor_list"`
+	PredecessorList []NodeStatus   `json:"predecessor_list"`
+	SuccessorRtt    string         `json:"successor_rtt"`   // the smoothed round-trip time to the successor
+	PredecessorRtt  string         `json:"predecessor_rtt"` // the smoothed round-trip time to the Predecessor
+	Lookups         LookupStats    `json:"lookups"`
+}
+
+// nodeStatus returns the NodeStatus of the node, nil if the node is nil.
+func nodeStatus(node *Node) *NodeStatus {
+	if node == nil {
+		return nil
+	}
+	return &NodeStatus{Id: hex.EncodeToString(node.Id), Addr: node.Addr, Vnode: node.Vnode}
+}
+
+// nodeStatuses returns the NodeStatus of each node.
+func nodeStatuses(nodes []*Node) []NodeStatus {
+	statuses := make([]NodeStatus, 0, len(nodes))
+	for _, node := range nodes {
+		if node != nil {
+			statuses = append(statuses, *nodeStatus(node))
+		}
+	}
+	return statuses
+}
+
+// Status returns the current ring state of the server.
+func (s *ChordRpcServer) Status() Status {
+	lookups := s.LookupStats()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	status := Status{
+		Self:            *nodeStatus(s.Self),
+		Predecessor:     nodeStatus(s.predecessor),
+		Fingers:         make([]FingerStatus, len(s.finger)),
+		SuccessorList:   nodeStatuses(s.successorList),
+		PredecessorList: nodeStatuses(s.predecessorList),
+		SuccessorRtt:    s.detector.Rtt(s.successor()).String(),
+		PredecessorRtt:  s.detector.Rtt(s.predecessor).String(),
+		Lookups:         lookups,
+	}
+	for i, node := range s.finger {
+		status.Fingers[i] = FingerStatus{
+			Start: hex.EncodeToString(utils.AddBytesPower2(s.Self.Id, i)),
+			Node:  nodeStatus(node),
+		}
+	}
+	return status
+}
+
+// Joined returns whether we have joined the Chord network, or created a new one as no bootstrapper is known, since the
+// P2P server started. Running as a lone ring after the bootstrappers failed to answer does not count.
+func (s *P2pServer) Joined() bool {
+	return atomic.LoadInt32(&s.joined) == 1
+}
+
+// Ready returns nil if we have joined the Chord network, are not left alone by the other nodes if any bootstrapper is
+// known, and all our virtual nodes know their Predecessor, i.e. the requests can be served, otherwise an error telling
+// what is missing.
+func (s *P2pServer) Ready() error {
+	if !s.Joined() {
+		return errors.New("not joined yet")
+	}
+	if atomic.LoadInt32(&s.expectPeers) == 1 && s.alone() {
+		return errors.New("running as a lone ring")
+	}
+	for _, server := range s.VirtualServers {
+		if server.Predecessor() == nil {
+			return fmt.Errorf("virtual node %d has no predecessor yet", server.Self.Vnode)
+		}
+	}
+	return nil
+}
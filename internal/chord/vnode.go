@@ -0,0 +1,166 @@
+package chord
+
+import (
+	"DHT/internal/chord/proto"
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strconv"
+)
+
+// vnodeRouter defines a proto.ChordServer dispatching each request to the virtual node it is sent to, so that all the
+// virtual nodes of a P2pServer share one grpc listener.
+type vnodeRouter struct {
+	proto.UnimplementedChordServer
+	servers []*ChordRpcServer // the virtual nodes, indexed by Node.Vnode
+}
+
+// server returns the virtual node which the request of the given context is sent to, the virtual node 0 by default.
+func (r *vnodeRouter) server(ctx context.Context) (*ChordRpcServer, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(VNODE_METADATA_KEY)
+	if len(values) == 0 {
+		return r.servers[0], nil
+	}
+	vnode, err := strconv.Atoi(values[0])
+	if err != nil || vnode < 0 || vnode >= len(r.servers) {
+		return nil, status.Errorf(codes.NotFound, "virtual node %q not found", values[0])
+	}
+	return r.servers[vnode], nil
+}
+
+// FindSuccessor dispatches the request to the virtual node.
+func (r *vnodeRouter) FindSuccessor(ctx context.Context, id *proto.Id) (*proto.Node, error) {
+	s, err := r.server(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.FindSuccessor(ctx, id)
+}
+
+// TraceSuccessor dispatches the request to the virtual node.
+func (r *vnodeRouter) TraceSuccessor(ctx context.Context, id *proto.Id) (*proto.Trace, error) {
+	s, err := r.server(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.TraceSuccessor(ctx, id)
+}
+
+// ClosestPrecedingFinger dispatches the request to the virtual node.
+func (r *vnodeRouter) ClosestPrecedingFinger(ctx context.Context, id *proto.Id) (*proto.LookupStep, error) {
+	s, err := r.server(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.ClosestPrecedingFinger(ctx, id)
+}
+
+// Notify dispatches the request to the virtual node.
+func (r *vnodeRouter) Notify(ctx context.Context, nn *proto.Node) (*proto.SuccessorList, error) {
+	s, err := r.server(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.Notify(ctx, nn)
+}
+
+// GetPredecessor dispatches the request to the virtual node.
+func (r *vnodeRouter) GetPredecessor(ctx context.Context, in *proto.Void) (*proto.Node, error) {
+	s, err := r.server(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPredecessor(ctx, in)
+}
+
+// GetPredecessorList dispatches the request to the virtual node.
+func (r *vnodeRouter) GetPredecessorList(ctx context.Context, in *proto.Void) (*proto.PredecessorList, error) {
+	s, err := r.server(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPredecessorList(ctx, in)
+}
+
+// Ping dispatches the request to the virtual node.
+func (r *vnodeRouter) Ping(ctx context.Context, in *proto.Void) (*proto.Void, error) {
+	s, err := r.server(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.Ping(ctx, in)
+}
+
+// Put dispatches the request to the virtual node.
+func (r *vnodeRouter) Put(ctx context.Context, req *proto.PutReq) (*proto.Void, error) {
+	s, err := r.server(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.Put(ctx, req)
+}
+
+// Get dispatches the request to the virtual node.
+func (r *vnodeRouter) Get(ctx context.Context, req *proto.GetReq) (*proto.GetResp, error) {
+	s, err := r.server(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, req)
+}
+
+// BatchPut dispatches the request to the virtual node.
+func (r *vnodeRouter) BatchPut(ctx context.Context, req *proto.BatchPutReq) (*proto.Void, error) {
+	s, err := r.server(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.BatchPut(ctx, req)
+}
+
+// BatchGet dispatches the request to the virtual node.
+func (r *vnodeRouter) BatchGet(ctx context.Context, req *proto.BatchGetReq) (*proto.BatchGetResp, error) {
+	s, err := r.server(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.BatchGet(ctx, req)
+}
+
+// CondPut dispatches the request to the virtual node.
+func (r *vnodeRouter) CondPut(ctx context.Context, req *proto.CondPutReq) (*proto.CondPutResp, error) {
+	s, err := r.server(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.CondPut(ctx, req)
+}
+
+// Watch dispatches the request to the virtual node.
+func (r *vnodeRouter) Watch(req *proto.WatchReq, stream proto.Chord_WatchServer) error {
+	s, err := r.server(stream.Context())
+	if err != nil {
+		return err
+	}
+	return s.Watch(req, stream)
+}
+
+// Touch dispatches the request to the virtual node.
+func (r *vnodeRouter) Touch(ctx context.Context, req *proto.TouchReq) (*proto.TouchResp, error) {
+	s, err := r.server(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.Touch(ctx, req)
+}
+
+// Delete dispatches the request to the virtual node.
+func (r *vnodeRouter) Delete(ctx context.Context, req *proto.DeleteReq) (*proto.DeleteResp, error) {
+	s, err := r.server(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.Delete(ctx, req)
+}
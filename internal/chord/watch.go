@@ -0,0 +1,102 @@
+package chord
+
+import (
+	"DHT/internal/chord/proto"
+	"DHT/internal/logger"
+	"DHT/internal/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync"
+)
+
+const WATCH_BUFFER_SIZE = 64 // max number of events buffered for a slow watcher, the newer events are dropped if full
+
+// watchers defines the watchers of keys in our storage, each of which is a channel receiving the changes of a key.
+type watchers struct {
+	chans map[string]map[chan *proto.Event]struct{} // the channels of watchers, keyed by the watched key
+	done  chan struct{}                             // closed on shutdown to end all the watches, created lazily
+	mutex sync.Mutex
+}
+
+// stopped returns the channel closed once stop is called.
+func (w *watchers) stopped() <-chan struct{} {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.done == nil {
+		w.done = make(chan struct{})
+	}
+	return w.done
+}
+
+// stop ends all the watches, current and future, so that the grpc server can stop gracefully.
+func (w *watchers) stop() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.done == nil {
+		w.done = make(chan struct{})
+	}
+	select {
+	case <-w.done:
+	default:
+		close(w.done)
+	}
+}
+
+// add registers a new watcher of the given key, and returns its channel.
+func (w *watchers) add(key []byte) chan *proto.Event {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.chans == nil {
+		w.chans = make(map[string]map[chan *proto.Event]struct{})
+	}
+	ch := make(chan *proto.Event, WATCH_BUFFER_SIZE)
+	if w.chans[string(key)] == nil {
+		w.chans[string(key)] = make(map[chan *proto.Event]struct{})
+	}
+	w.chans[string(key)][ch] = struct{}{}
+	return ch
+}
+
+// remove unregisters the watcher of the given key and channel.
+func (w *watchers) remove(key []byte, ch chan *proto.Event) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	delete(w.chans[string(key)], ch)
+	if len(w.chans[string(key)]) == 0 {
+		delete(w.chans, string(key))
+	}
+}
+
+// onStorageEvent pushes a change in our storage to the watchers of the key, without blocking.
+func (w *watchers) onStorageEvent(ev storage.Event) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for ch := range w.chans[string(ev.Key)] {
+		select {
+		case ch <- &proto.Event{Type: int32(ev.Type), Key: ev.Key, Value: ev.Value, Version: ev.Version}:
+		default:
+			logger.Logger.Warnw("watcher is too slow, event dropped", "key", string(ev.Key), "type", ev.Type)
+		}
+	}
+}
+
+// Watch asks us to push the changes of the given key in our storage, until the stream is closed or we are shutting
+// down, in which case the watcher should watch the new responsible node.
+func (s *ChordRpcServer) Watch(req *proto.WatchReq, stream proto.Chord_WatchServer) (err error) {
+	defer logFunc("s.Watch", req, nil, err)
+	ch := s.watchers.add(req.GetKey())
+	defer s.watchers.remove(req.GetKey(), ch)
+	stopped := s.watchers.stopped()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-stopped:
+			return status.Error(codes.Unavailable, "the server is shutting down")
+		case ev := <-ch:
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+		}
+	}
+}
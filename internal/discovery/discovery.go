@@ -0,0 +1,177 @@
+package discovery
+
+import (
+	"DHT/internal/logger"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Here defines the defaults and the parameters of the Discovery.
+const (
+	DEFAULT_CLUSTER  = "dht"       // default name of the cluster
+	DEFAULT_INTERVAL = time.Second // default interval of announcing ourselves
+	PEER_TTL_ROUNDS  = 3           // number of intervals after which a node not announcing itself is forgotten
+	WAIT_ROUNDS      = 3           // number of intervals waited for the existing nodes to announce themselves on startup
+	MAX_PACKET_SIZE  = 1024        // max #bytes of an announcement
+)
+
+// Config defines the parameters of a Discovery.
+type Config struct {
+	Group    string        // the UDP multicast group of format ip:port, empty to disable the discovery
+	Cluster  string        // the name of the cluster, only the nodes of the same cluster discover each other
+	Interval time.Duration // the interval of announcing ourselves
+}
+
+// announcement defines the message multicast by a node periodically to announce itself.
+type announcement struct {
+	Cluster string `json:"cluster"` // the name of the cluster of the node
+	Addr    string `json:"addr"`    // the P2P address of the node
+	Id      string `json:"id"`      // the hex id of the node
+}
+
+// Discovery defines the discovery of the nodes of the same cluster on the local network, by multicasting the P2P
+// address and the id of each node periodically.
+type Discovery struct {
+	config Config
+	group  *net.UDPAddr // the multicast group announced to
+	self   announcement // our announcement
+	conn   *net.UDPConn // the connection receiving the announcements of the group
+
+	mutex sync.Mutex
+	peers map[string]time.Time // the time when each node discovered is last announced, keyed by P2P address
+	found chan struct{}        // closed once the first node is discovered
+}
+
+// New creates a Discovery announcing the given P2P address and id, and joins the multicast group.
+func New(config Config, addr string, id []byte) (*Discovery, error) {
+	if config.Cluster == "" {
+		config.Cluster = DEFAULT_CLUSTER
+	}
+	if config.Interval <= 0 {
+		config.Interval = DEFAULT_INTERVAL
+	}
+	group, err := net.ResolveUDPAddr("udp4", config.Group)
+	if err != nil {
+		return nil, err
+	}
+	if !group.IP.IsMulticast() {
+		return nil, fmt.Errorf("%s is not a multicast address", config.Group)
+	}
+	conn, err := net.ListenMulticastUDP("udp4", nil, group)
+	if err != nil {
+		return nil, err
+	}
+	return &Discovery{
+		config: config,
+		group:  group,
+		self:   announcement{Cluster: config.Cluster, Addr: addr, Id: fmt.Sprintf("%x", id)},
+		conn:   conn,
+		peers:  make(map[string]time.Time),
+		found:  make(chan struct{}),
+	}, nil
+}
+
+// Close leaves the multicast group, which is only needed if the Discovery is never served.
+func (d *Discovery) Close() error {
+	return d.conn.Close()
+}
+
+// Serve announces ourselves periodically and receives the announcements of the other nodes, until the given context
+// is canceled.
+func (d *Discovery) Serve(ctx context.Context) error {
+	sender, err := net.DialUDP("udp4", nil, d.group)
+	if err != nil {
+		d.conn.Close()
+		return err
+	}
+	defer sender.Close()
+	go func() {
+		<-ctx.Done()
+		d.conn.Close()
+	}()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- d.receive()
+	}()
+
+	packet, _ := json.Marshal(d.self)
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := sender.Write(packet); err != nil {
+			logger.Logger.Warnw("discovery announce error", "group", d.group, "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			<-errs
+			return nil
+		case err := <-errs:
+			return err
+		case <-ticker.C:
+		}
+	}
+}
+
+// receive records the announcements of the nodes of our cluster until the connection is closed.
+func (d *Discovery) receive() error {
+	buf := make([]byte, MAX_PACKET_SIZE)
+	for {
+		n, from, err := d.conn.ReadFromUDP(buf)
+		if err != nil {
+			return err
+		}
+		var a announcement
+		if err := json.Unmarshal(buf[:n], &a); err != nil {
+			logger.Logger.Debugw("discovery malformed announcement", "from", from, "err", err)
+			continue
+		}
+		if a.Cluster != d.self.Cluster || a.Addr == d.self.Addr || a.Addr == "" {
+			continue
+		}
+		d.mutex.Lock()
+		if _, ok := d.peers[a.Addr]; !ok {
+			logger.Logger.Infow("discovery found node", "addr", a.Addr, "id", a.Id, "from", from)
+		}
+		d.peers[a.Addr] = time.Now()
+		select {
+		case <-d.found:
+		default:
+			close(d.found)
+		}
+		d.mutex.Unlock()
+	}
+}
+
+// Discover returns the P2P addresses of the nodes of our cluster which have announced themselves recently.
+func (d *Discovery) Discover() []string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	var addrs []string
+	for addr, lastSeen := range d.peers {
+		if time.Since(lastSeen) > PEER_TTL_ROUNDS*d.config.Interval {
+			delete(d.peers, addr)
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+// Wait blocks until any node of our cluster is discovered, or the existing nodes should have announced themselves,
+// and returns the nodes discovered.
+func (d *Discovery) Wait(ctx context.Context) []string {
+	timer := time.NewTimer(WAIT_ROUNDS * d.config.Interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-d.found:
+	case <-timer.C:
+	}
+	return d.Discover()
+}
@@ -5,31 +5,123 @@ import (
 	"fmt"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"os"
+	"sync"
 )
 
 var Logger *zap.SugaredLogger
 
-// Init the logger
+var (
+	level  = zap.NewAtomicLevel() // the level of the Logger, which can be changed while logging
+	output = &logFile{}           // the file of the Logger, which can be replaced while logging
+	once   sync.Once
+
+	initMutex   sync.Mutex // serializes Init and InitIfNeeded
+	initialized bool       // whether Init has succeeded, guarded by initMutex
+)
+
+// logFile defines a zapcore.WriteSyncer writing to a file which can be replaced while logging.
+type logFile struct {
+	mutex sync.Mutex
+	path  string   // the path of the current file
+	file  *os.File // the current file, nil before Init
+}
+
+// Write writes the log entry to the current file.
+func (f *logFile) Write(p []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.file == nil {
+		return len(p), nil
+	}
+	return f.file.Write(p)
+}
+
+// Sync flushes the current file.
+func (f *logFile) Sync() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+// open replaces the current file with the file of the given path, which is appended to.
+func (f *logFile) open(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.file != nil {
+		f.file.Close()
+	}
+	f.path, f.file = path, file
+	return nil
+}
+
+// reopen opens the file of the current path again, which may have been moved away by log rotation.
+func (f *logFile) reopen() error {
+	f.mutex.Lock()
+	path := f.path
+	f.mutex.Unlock()
+	if path == "" {
+		return nil
+	}
+	return f.open(path)
+}
+
+// Init the logger. The Logger is created once, and the later calls only switch its file and level, so that it can be
+// used by other goroutines meanwhile.
 func Init(logFilePath string, logLevel zapcore.Level) error {
-	const LOG_FOLDER string = "./logs"
-	if Logger != nil {
-		Sync()
+	initMutex.Lock()
+	defer initMutex.Unlock()
+	return initLocked(logFilePath, logLevel)
+}
+
+// InitIfNeeded inits the logger unless it has been initialized, e.g. by another node embedded in the same process,
+// in which case the file and the level of the logger are left alone.
+func InitIfNeeded(logFilePath string, logLevel zapcore.Level) error {
+	initMutex.Lock()
+	defer initMutex.Unlock()
+	if initialized {
+		return nil
 	}
+	return initLocked(logFilePath, logLevel)
+}
+
+// initLocked inits the logger, which should be called with initMutex held.
+func initLocked(logFilePath string, logLevel zapcore.Level) error {
+	const LOG_FOLDER string = "./logs"
 	utils.CheckAndMakeDir(LOG_FOLDER)
-	//cfg := zap.NewProductionConfig()
-	cfg := zap.NewProductionConfig()
-	cfg.OutputPaths = []string{LOG_FOLDER + "/" + logFilePath}
-	cfg.Level = zap.NewAtomicLevelAt(logLevel)
-	cfg.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
-	cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	if logger, err := cfg.Build(); err != nil {
+	if err := output.open(LOG_FOLDER + "/" + logFilePath); err != nil {
 		return err
-	} else {
-		Logger = logger.Sugar()
 	}
+	level.SetLevel(logLevel)
+	once.Do(func() {
+		cfg := zap.NewProductionEncoderConfig()
+		cfg.EncodeLevel = zapcore.CapitalLevelEncoder
+		cfg.EncodeTime = zapcore.ISO8601TimeEncoder
+		core := zapcore.NewCore(zapcore.NewJSONEncoder(cfg), output, level)
+		Logger = zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel)).Sugar()
+	})
+	initialized = true
 	return nil
 }
 
+// Reopen reopens the log file at the same path, so that the log entries are written to a new file after the old one
+// is moved away by log rotation.
+func Reopen() error {
+	return output.reopen()
+}
+
+// SetLevel changes the level of the Logger, which applies to the log entries written afterwards.
+func SetLevel(logLevel zapcore.Level) {
+	level.SetLevel(logLevel)
+}
+
 // Sync flushes any buffered log entries.
 func Sync() {
 	if Logger != nil {
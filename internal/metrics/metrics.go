@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"path"
+	"time"
+)
+
+const NAMESPACE = "dht" // the prefix of the names of all the metrics
+
+// Metrics defines the Prometheus metrics of a DHT server. They are registered on a registry of their own rather than
+// the global one, so that several servers can run in one process. All the methods are no-ops on a nil Metrics.
+type Metrics struct {
+	Registry          *prometheus.Registry     // the registry of all the metrics, served by Handler
+	apiRequests       *prometheus.HistogramVec // the latencies of the API requests, by message type and response type
+	apiRejections     *prometheus.CounterVec   // the API requests rejected, by reason
+	rpcRequests       *prometheus.HistogramVec // the latencies of the RPCs served, by method and status code
+	lookups           *prometheus.CounterVec   // the lookups initiated by us, by result
+	lookupHops        prometheus.Histogram     // the hop counts of the lookups succeeded
+	maintenanceErrors *prometheus.CounterVec   // the errors of the maintenance tasks, by task
+}
+
+// New creates the Metrics, with the metrics of the Go runtime and the process registered as well.
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		apiRequests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: NAMESPACE,
+			Name:      "api_request_duration_seconds",
+			Help:      "Latencies of the API requests, by message type and response type.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"type", "response"}),
+		apiRejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: NAMESPACE,
+			Name:      "api_requests_rejected_total",
+			Help:      "API requests rejected with DHT_ERROR, by reason.",
+		}, []string{"reason"}),
+		rpcRequests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: NAMESPACE,
+			Name:      "rpc_request_duration_seconds",
+			Help:      "Latencies of the RPCs served to the other nodes, by method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+		lookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: NAMESPACE,
+			Name:      "lookups_total",
+			Help:      "Lookups initiated by this node, by result.",
+		}, []string{"result"}),
+		lookupHops: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: NAMESPACE,
+			Name:      "lookup_hops",
+			Help:      "Hop counts of the lookups succeeded.",
+			Buckets:   prometheus.LinearBuckets(0, 1, 17),
+		}),
+		maintenanceErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: NAMESPACE,
+			Name:      "maintenance_errors_total",
+			Help:      "Errors of the maintenance tasks, e.g. Stabilize, by task.",
+		}, []string{"task"}),
+	}
+	m.Registry.MustRegister(
+		m.apiRequests,
+		m.apiRejections,
+		m.rpcRequests,
+		m.lookups,
+		m.lookupHops,
+		m.maintenanceErrors,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Gauge registers a gauge of the given name and help, whose value is got from the given function on each scrape.
+func (m *Metrics) Gauge(name string, help string, value func() float64) {
+	if m == nil {
+		return
+	}
+	m.Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: NAMESPACE,
+		Name:      name,
+		Help:      help,
+	}, value))
+}
+
+// Handler returns the HTTP handler serving all the metrics in the Prometheus text format, or 404 on a nil Metrics.
+func (m *Metrics) Handler() http.Handler {
+	if m == nil {
+		return http.NotFoundHandler()
+	}
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveApiRequest records an API request of the given message type answered with the given response type.
+func (m *Metrics) ObserveApiRequest(msgType string, response string, latency time.Duration) {
+	if m == nil {
+		return
+	}
+	m.apiRequests.WithLabelValues(msgType, response).Observe(latency.Seconds())
+}
+
+// RejectApiRequest records an API request rejected for the given reason.
+func (m *Metrics) RejectApiRequest(reason string) {
+	if m == nil {
+		return
+	}
+	m.apiRejections.WithLabelValues(reason).Inc()
+}
+
+// ObserveLookup records a lookup initiated by us, which took the given number of hops if succeeded.
+func (m *Metrics) ObserveLookup(hops int, err error) {
+	if m == nil {
+		return
+	}
+	if err != nil {
+		m.lookups.WithLabelValues("error").Inc()
+		return
+	}
+	m.lookups.WithLabelValues("ok").Inc()
+	m.lookupHops.Observe(float64(hops))
+}
+
+// MaintenanceError records an error of the given maintenance task.
+func (m *Metrics) MaintenanceError(task string) {
+	if m == nil {
+		return
+	}
+	m.maintenanceErrors.WithLabelValues(task).Inc()
+}
+
+// observeRpc records an RPC of the given full method name, e.g. /proto.Chord/FindSuccessor.
+func (m *Metrics) observeRpc(fullMethod string, err error, latency time.Duration) {
+	if m == nil {
+		return
+	}
+	m.rpcRequests.WithLabelValues(path.Base(fullMethod), status.Code(err).String()).Observe(latency.Seconds())
+}
+
+// UnaryServerInterceptor records the latency of each unary RPC served.
+func (m *Metrics) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	m.observeRpc(info.FullMethod, err, time.Since(start))
+	return resp, err
+}
+
+// StreamServerInterceptor records the duration of each streaming RPC served.
+func (m *Metrics) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	m.observeRpc(info.FullMethod, err, time.Since(start))
+	return err
+}
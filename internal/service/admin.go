@@ -0,0 +1,101 @@
+package service
+
+import (
+	"DHT/internal/chord"
+	"DHT/internal/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// StoreStatus defines the statistics of the local storage in the Status.
+type StoreStatus struct {
+	Keys  int   `json:"keys"`  // the number of the keys, including the expired ones not deleted yet
+	Bytes int64 `json:"bytes"` // the total bytes of the keys and the records
+}
+
+// Status defines the status of a server served on /status.
+type Status struct {
+	Ready  bool           `json:"ready"`
+	Reason string         `json:"reason,omitempty"` // why the server is not ready
+	Nodes  []chord.Status `json:"nodes"`            // the ring state of each virtual node, indexed by vnode
+	Store  StoreStatus    `json:"store"`
+}
+
+// ready returns nil if the server can serve requests, i.e. it has joined the Chord network and all the virtual nodes
+// know their successor and predecessor, and it is not shutting down, otherwise an error telling why not.
+func (s *Server) ready(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return errors.New("shutting down")
+	}
+	return s.P2pServer.Ready()
+}
+
+// Status returns the current status of the server, where ctx is the one of Serve, which is canceled on shutdown.
+func (s *Server) Status(ctx context.Context) Status {
+	status := Status{
+		Ready: true,
+		Store: StoreStatus{Keys: s.Storage.Len(), Bytes: s.Storage.Size()},
+	}
+	if err := s.ready(ctx); err != nil {
+		status.Ready, status.Reason = false, err.Error()
+	}
+	for _, server := range s.P2pServer.VirtualServers {
+		status.Nodes = append(status.Nodes, server.Status())
+	}
+	return status
+}
+
+// writeJson writes the value as the JSON body of the response of the given status code.
+func writeJson(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Logger.Warnw("http response error", "err", err)
+	}
+}
+
+// httpHandler returns the handler of the HTTP address, serving:
+//   - /metrics: the Prometheus metrics.
+//   - /healthz: 200 as long as the server is running.
+//   - /readyz: 200 once ready, as ready tells, otherwise 503 with the reason.
+//   - /status: the Status in JSON.
+//
+// ctx is the one of Serve, which is canceled on shutdown.
+func (s *Server) httpHandler(ctx context.Context) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.Metrics.Handler())
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if err := s.ready(ctx); err != nil {
+			writeJson(w, http.StatusServiceUnavailable, map[string]interface{}{"ready": false, "reason": err.Error()})
+			return
+		}
+		writeJson(w, http.StatusOK, map[string]interface{}{"ready": true})
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusOK, s.Status(ctx))
+	})
+	return mux
+}
+
+// serveHttp serves the httpHandler on the HTTP address until ctx is canceled, if enabled, where serveCtx is the one
+// of Serve.
+func (s *Server) serveHttp(ctx context.Context, serveCtx context.Context) error {
+	if s.httpListener == nil {
+		return nil
+	}
+	server := &http.Server{Handler: s.httpHandler(serveCtx), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	if err := server.Serve(s.httpListener); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,360 @@
+package service
+
+import (
+	"DHT/internal/chord"
+	"DHT/internal/discovery"
+	"DHT/internal/utils"
+	"fmt"
+	"gopkg.in/ini.v1"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const ENV_PREFIX = "DHT_" // the prefix of the environment variables overriding the keys, e.g. DHT_P2P_ADDRESS
+
+// Key defines a key in the [dht] section of the configuration file.
+type Key struct {
+	Name  string                 // the name of the key
+	Usage string                 // the description of the key
+	value func(p *Params) string // returns the effective value of the key
+}
+
+// Env returns the name of the environment variable overriding the key.
+func (k Key) Env() string {
+	return ENV_PREFIX + strings.ToUpper(k.Name)
+}
+
+// Keys defines all the keys supported in the [dht] section of the configuration file, in the order of the README.
+var Keys = []Key{
+	{"bootstrapper", "comma separated P2P addresses of the nodes in the existing network, tried in order, empty to create a new network", func(p *Params) string { return strings.Join(p.Bootstrappers, ",") }},
+	{"p2p_address", "address listened on for the other nodes, of format ip:port", func(p *Params) string { return p.P2pAddress }},
+	{"api_address", "address listened on for the API clients, of format ip:port", func(p *Params) string { return p.ApiAddress }},
+	{"http_address", "address serving the Prometheus metrics and the /healthz, /readyz and /status endpoints over HTTP, of format ip:port, empty to disable", func(p *Params) string { return p.HttpAddress }},
+	{"log_file", "name of the log file under ./logs", func(p *Params) string { return p.LogFile }},
+	{"log_level", "min level of the log entries, debug, info, warn or error", func(p *Params) string { return p.LogLevel.String() }},
+	{"data_file", "name of the data file under ./data", func(p *Params) string { return p.DataFile }},
+	{"ca_cert", "path of the certificate of the CA", func(p *Params) string { return p.CACert }},
+	{"hostkey", "path of the private key of this node", func(p *Params) string { return p.ServerKey }},
+	{"hostcert", "path of the certificate of this node, signed by the CA", func(p *Params) string { return p.ServerCert }},
+	{"max_connections", "max number of concurrent API connections, 0 means unlimited", func(p *Params) string { return strconv.Itoa(p.ApiConfig.MaxConnections) }},
+	{"max_inflight_requests", "max number of in-flight API requests on each connection, 0 means unlimited", func(p *Params) string { return strconv.Itoa(p.ApiConfig.MaxInFlight) }},
+	{"rate_limit", "max number of API requests per second from each client IP, 0 means unlimited", func(p *Params) string { return formatFloat(p.ApiConfig.RateLimit) }},
+	{"rate_burst", "max burst size of API requests from each client IP, 0 means the same as rate_limit", func(p *Params) string { return strconv.Itoa(p.ApiConfig.RateBurst) }},
+	{"lookup_mode", "mode of lookups initiated by this node, recursive or iterative", func(p *Params) string { return p.ChordConfig.LookupMode.String() }},
+	{"virtual_nodes", "number of virtual nodes run by this node, proportional to its capacity", func(p *Params) string { return strconv.Itoa(p.ChordConfig.VirtualNodes) }},
+	{"max_replication", "max replication accepted in API requests, 0 means unlimited", func(p *Params) string { return strconv.Itoa(p.ApiConfig.MaxReplication) }},
+	{"successor_list_size", "number of other servers spanned by the successor list, raised to max_replication if smaller", func(p *Params) string { return strconv.Itoa(p.ChordConfig.SuccessorListSize) }},
+	{"finger_refresh", "strategy of refreshing the finger table, full or random", func(p *Params) string { return p.ChordConfig.FingerRefresh.String() }},
+	{"phi_threshold", "suspicion level of the failure detector above which a neighbour is declared dead", func(p *Params) string { return formatFloat(p.ChordConfig.PhiThreshold) }},
+	{"ping_timeout", "timeout of each ping to a neighbour", func(p *Params) string { return p.ChordConfig.PingTimeout.String() }},
+	{"check_interval", "min interval of checking the predecessor and the successor", func(p *Params) string { return p.ChordConfig.CheckInterval.String() }},
+	{"stabilize_interval", "min interval of stabilizing", func(p *Params) string { return p.ChordConfig.StabilizeInterval.String() }},
+	{"fix_fingers_interval", "min interval of fixing fingers", func(p *Params) string { return p.ChordConfig.FixFingersInterval.String() }},
+	{"max_maintenance_interval", "max interval which the maintenance tasks back off to while the ring is stable", func(p *Params) string { return p.ChordConfig.MaxInterval.String() }},
+	{"maintenance_jitter", "max fraction by which the maintenance intervals are randomly shifted, in [0, 1)", func(p *Params) string { return formatFloat(p.ChordConfig.Jitter) }},
+	{"lookup_timeout", "deadline of each lookup", func(p *Params) string { return p.ChordConfig.LookupTimeout.String() }},
+	{"put_timeout", "deadline of each put", func(p *Params) string { return p.ChordConfig.PutTimeout.String() }},
+	{"get_timeout", "deadline of each get", func(p *Params) string { return p.ChordConfig.GetTimeout.String() }},
+	{"join_retry_interval", "interval before retrying to join after all the bootstrappers fail, doubling on each failure", func(p *Params) string { return p.ChordConfig.JoinRetryInterval.String() }},
+	{"join_max_interval", "max interval which the retries of joining back off to", func(p *Params) string { return p.ChordConfig.JoinMaxInterval.String() }},
+	{"join_timeout", "time spent on joining at startup before giving up", func(p *Params) string { return p.ChordConfig.JoinTimeout.String() }},
+	{"retry_join", "whether to run as a lone ring rather than fail once join_timeout is exceeded, retrying to join in background", func(p *Params) string { return strconv.FormatBool(p.ChordConfig.RetryJoin) }},
+	{"discovery_group", "UDP multicast group of format ip:port to discover the nodes on the local network if no bootstrapper is specified, empty to disable", func(p *Params) string { return p.DiscoveryConfig.Group }},
+	{"cluster", "name of the cluster, only the nodes of the same cluster discover each other", func(p *Params) string { return p.DiscoveryConfig.Cluster }},
+	{"discovery_interval", "interval of announcing this node to the discovery group", func(p *Params) string { return p.DiscoveryConfig.Interval.String() }},
+	{"drain_timeout", "max time waited for the in-flight requests on shutdown", func(p *Params) string { return p.DrainTimeout.String() }},
+}
+
+// findKey returns the Key of the given name, if supported.
+func findKey(name string) (Key, bool) {
+	for _, key := range Keys {
+		if key.Name == name {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+// formatFloat formats a float value of a key in the shortest form.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// ConfigError defines the problems found in a configuration, each naming the key to fix.
+type ConfigError struct {
+	Problems []string
+}
+
+// Error returns all the problems, one per line.
+func (e *ConfigError) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// addf records a problem of the configuration.
+func (e *ConfigError) addf(format string, args ...interface{}) {
+	e.Problems = append(e.Problems, fmt.Sprintf(format, args...))
+}
+
+// err returns the ConfigError if any problem is recorded, otherwise nil.
+func (e *ConfigError) err() error {
+	if len(e.Problems) == 0 {
+		return nil
+	}
+	return e
+}
+
+// keyParser parses the values of the keys in a section strictly, recording a problem for each malformed value
+// instead of falling back to the default silently.
+type keyParser struct {
+	section  *ini.Section
+	problems *ConfigError
+}
+
+// string returns the trimmed value of the key.
+func (p *keyParser) string(name string) string {
+	return strings.TrimSpace(p.section.Key(name).String())
+}
+
+// stringOr returns the trimmed value of the key, or the default if the key is absent or empty.
+func (p *keyParser) stringOr(name string, def string) string {
+	if s := p.string(name); s != "" {
+		return s
+	}
+	return def
+}
+
+// list returns the comma separated values of the key, with the empty ones dropped.
+func (p *keyParser) list(name string) []string {
+	var values []string
+	for _, value := range strings.Split(p.string(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// bool returns the boolean value of the key, or the default if the key is absent or empty.
+func (p *keyParser) bool(name string, def bool) bool {
+	s := p.string(name)
+	if s == "" {
+		return def
+	}
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		p.problems.addf("%s: %q is not a boolean, should be true or false", name, s)
+		return def
+	}
+	return v
+}
+
+// int returns the integer value of the key, or the default if the key is absent or empty.
+func (p *keyParser) int(name string, def int) int {
+	s := p.string(name)
+	if s == "" {
+		return def
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		p.problems.addf("%s: %q is not an integer, e.g. %d", name, s, def)
+		return def
+	}
+	return v
+}
+
+// float returns the float value of the key, or the default if the key is absent or empty.
+func (p *keyParser) float(name string, def float64) float64 {
+	s := p.string(name)
+	if s == "" {
+		return def
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		p.problems.addf("%s: %q is not a number, e.g. %s", name, s, formatFloat(def))
+		return def
+	}
+	return v
+}
+
+// duration returns the duration value of the key, or the default if the key is absent or empty.
+func (p *keyParser) duration(name string, def time.Duration) time.Duration {
+	s := p.string(name)
+	if s == "" {
+		return def
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		p.problems.addf("%s: %q is not a duration with a unit, e.g. %v", name, s, def)
+		return def
+	}
+	return v
+}
+
+// loadSection loads the [dht] section of the configuration file, or an empty one if no file is given, and applies
+// the DHT_* environment variables and then the given overrides keyed by the key names on it.
+func loadSection(configurationFile string, overrides map[string]string, problems *ConfigError) (*ini.Section, error) {
+	cfg := ini.Empty()
+	if configurationFile != "" {
+		var err error
+		if cfg, err = ini.Load(configurationFile); err != nil {
+			return nil, err
+		}
+	}
+	section := cfg.Section("dht")
+	for _, key := range section.Keys() {
+		if _, ok := findKey(key.Name()); !ok {
+			problems.addf("%s: unknown key in %s, see the README for the supported keys", key.Name(), configurationFile)
+		}
+	}
+	for _, key := range Keys {
+		if value, ok := os.LookupEnv(key.Env()); ok {
+			section.Key(key.Name).SetValue(value)
+		}
+	}
+	for name, value := range overrides {
+		if _, ok := findKey(name); !ok {
+			problems.addf("%s: unknown key overridden, see the README for the supported keys", name)
+			continue
+		}
+		section.Key(name).SetValue(value)
+	}
+	return section, nil
+}
+
+// checkAddress records a problem if the address of the key is not of format ip:port.
+func checkAddress(problems *ConfigError, name string, address string) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || host == "" {
+		problems.addf("%s: %q is not of format ip:port, e.g. 127.0.0.1:7402", name, address)
+		return
+	}
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > math.MaxUint16 {
+		problems.addf("%s: port %q of %q should be in [1, %d]", name, port, address, math.MaxUint16)
+	}
+}
+
+// Validate checks that the parameters are complete and consistent, and returns a ConfigError listing all the
+// problems found, if any.
+func (p *Params) Validate() error {
+	problems := &ConfigError{}
+	p.validate(problems)
+	return problems.err()
+}
+
+// validate records the problems of the parameters.
+func (p *Params) validate(problems *ConfigError) {
+	if p.P2pAddress == "" {
+		problems.addf("p2p_address: is required, e.g. 127.0.0.1:7402")
+	} else {
+		checkAddress(problems, "p2p_address", p.P2pAddress)
+	}
+	if p.ApiAddress == "" {
+		problems.addf("api_address: is required, e.g. 127.0.0.1:7401")
+	} else {
+		checkAddress(problems, "api_address", p.ApiAddress)
+	}
+	if p.ApiAddress != "" && p.ApiAddress == p.P2pAddress {
+		problems.addf("api_address: should differ from p2p_address %q", p.P2pAddress)
+	}
+	if p.HttpAddress != "" {
+		checkAddress(problems, "http_address", p.HttpAddress)
+		if p.HttpAddress == p.ApiAddress || p.HttpAddress == p.P2pAddress {
+			problems.addf("http_address: should differ from api_address and p2p_address")
+		}
+	}
+	for _, bootstrapper := range p.Bootstrappers {
+		checkAddress(problems, "bootstrapper", bootstrapper)
+		if bootstrapper == p.P2pAddress {
+			problems.addf("bootstrapper: %q should be another node, leave it empty to create a new network", bootstrapper)
+		}
+	}
+	if p.LogFile == "" {
+		problems.addf("log_file: is required, e.g. node0.log")
+	}
+	if p.DataFile == "" {
+		problems.addf("data_file: is required, e.g. data0.db")
+	}
+	for _, file := range []struct{ name, path string }{{"ca_cert", p.CACert}, {"hostcert", p.ServerCert}, {"hostkey", p.ServerKey}} {
+		if file.path == "" {
+			problems.addf("%s: is required", file.name)
+		} else if !utils.Exists(file.path) {
+			problems.addf("%s: file %q doesn't exist", file.name, file.path)
+		}
+	}
+
+	limits, config := p.ApiConfig, p.ChordConfig
+	if limits.MaxConnections < 0 {
+		problems.addf("max_connections: %d should not be negative, 0 means unlimited", limits.MaxConnections)
+	}
+	if limits.MaxInFlight < 0 {
+		problems.addf("max_inflight_requests: %d should not be negative, 0 means unlimited", limits.MaxInFlight)
+	}
+	if limits.RateLimit < 0 {
+		problems.addf("rate_limit: %s should not be negative, 0 means unlimited", formatFloat(limits.RateLimit))
+	}
+	if limits.RateBurst < 0 {
+		problems.addf("rate_burst: %d should not be negative, 0 means the same as rate_limit", limits.RateBurst)
+	}
+	if limits.MaxReplication < 0 || limits.MaxReplication > math.MaxUint8 {
+		problems.addf("max_replication: %d should be in [0, %d], 0 means unlimited", limits.MaxReplication, math.MaxUint8)
+	}
+	if config.VirtualNodes < 1 {
+		problems.addf("virtual_nodes: %d should be at least 1", config.VirtualNodes)
+	}
+	if config.PhiThreshold < 0 {
+		problems.addf("phi_threshold: %s should not be negative, e.g. %v", formatFloat(config.PhiThreshold), chord.DEFAULT_PHI_THRESHOLD)
+	}
+	if config.Jitter < 0 || config.Jitter >= 1 {
+		problems.addf("maintenance_jitter: %s should be in [0, 1)", formatFloat(config.Jitter))
+	}
+	if group := p.DiscoveryConfig.Group; group != "" {
+		checkAddress(problems, "discovery_group", group)
+		if host, _, err := net.SplitHostPort(group); err == nil {
+			if ip := net.ParseIP(host); ip == nil || ip.To4() == nil || !ip.IsMulticast() {
+				problems.addf("discovery_group: %q should be an IPv4 multicast address, e.g. 239.255.74.0:7400", host)
+			}
+		}
+		if p.DiscoveryConfig.Cluster == "" {
+			problems.addf("cluster: is required by the discovery, e.g. %s", discovery.DEFAULT_CLUSTER)
+		}
+	}
+	if config.JoinMaxInterval < config.JoinRetryInterval {
+		problems.addf("join_max_interval: %v should be at least join_retry_interval %v", config.JoinMaxInterval, config.JoinRetryInterval)
+	}
+	for _, d := range []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"ping_timeout", config.PingTimeout},
+		{"check_interval", config.CheckInterval},
+		{"stabilize_interval", config.StabilizeInterval},
+		{"fix_fingers_interval", config.FixFingersInterval},
+		{"max_maintenance_interval", config.MaxInterval},
+		{"lookup_timeout", config.LookupTimeout},
+		{"put_timeout", config.PutTimeout},
+		{"get_timeout", config.GetTimeout},
+		{"join_retry_interval", config.JoinRetryInterval},
+		{"join_max_interval", config.JoinMaxInterval},
+		{"join_timeout", config.JoinTimeout},
+		{"discovery_interval", p.DiscoveryConfig.Interval},
+		{"drain_timeout", p.DrainTimeout},
+	} {
+		if d.duration < 0 {
+			problems.addf("%s: %v should not be negative", d.name, d.duration)
+		}
+	}
+}
+
+// INI returns the parameters in the format of the configuration file, with every key set to its effective value.
+func (p *Params) INI() string {
+	sb := strings.Builder{}
+	sb.WriteString("[dht]\n")
+	for _, key := range Keys {
+		sb.WriteString(key.Name + " = " + key.value(p) + "\n")
+	}
+	return sb.String()
+}
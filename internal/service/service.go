@@ -3,93 +3,381 @@ package service
 import (
 	"DHT/internal/api"
 	"DHT/internal/chord"
+	"DHT/internal/discovery"
 	"DHT/internal/logger"
+	"DHT/internal/metrics"
 	"DHT/internal/storage"
-	"DHT/internal/utils"
+	"context"
+	"errors"
 	"fmt"
-	"go.uber.org/zap"
-	"gopkg.in/ini.v1"
-	"log"
+	"go.uber.org/zap/zapcore"
+	"net"
+	"strings"
+	"sync"
+	"time"
 )
 
+const DEFAULT_DRAIN_TIMEOUT = 10 * time.Second // default time waited for the in-flight requests on shutdown
+
 // Params defines the parameters for a server.
 type Params struct {
-	Bootstrapper           string
+	Bootstrappers          []string // the P2P addresses of the nodes in the existing network, tried in order
 	ApiAddress, P2pAddress string
+	HttpAddress            string // the address serving /metrics and the admin endpoints over HTTP, empty to disable
 	LogFile, DataFile      string
+	LogLevel               zapcore.Level // the min level of the log entries written
 	CACert                 string
 	ServerCert, ServerKey  string
+	DrainTimeout           time.Duration // how long the in-flight requests are waited for on shutdown
+	ApiConfig              api.Config
+	ChordConfig            chord.Config
+	DiscoveryConfig        discovery.Config
 }
 
-// readParams reads the parameters out from a configuration file.
-func readParams(configurationFile string) (*Params, error) {
-	cfg, err := ini.Load(configurationFile)
+// LoadParams reads the parameters out from the [dht] section of the configuration file, if any, overridden by the
+// DHT_* environment variables and then by the given overrides keyed by the key names, e.g. from command-line flags.
+// The parameters are validated strictly, and a ConfigError listing all the problems is returned if any.
+func LoadParams(configurationFile string, overrides map[string]string) (*Params, error) {
+	problems := &ConfigError{}
+	section, err := loadSection(configurationFile, overrides, problems)
 	if err != nil {
 		return nil, err
 	}
-	return &Params{
-		Bootstrapper: cfg.Section("dht").Key("bootstrapper").String(),
-		P2pAddress:   cfg.Section("dht").Key("p2p_address").String(),
-		ApiAddress:   cfg.Section("dht").Key("api_address").String(),
-		LogFile:      cfg.Section("dht").Key("log_file").String(),
-		DataFile:     cfg.Section("dht").Key("data_file").String(),
-		CACert:       cfg.Section("dht").Key("ca_cert").String(),
-		ServerCert:   cfg.Section("dht").Key("hostcert").String(),
-		ServerKey:    cfg.Section("dht").Key("hostkey").String(),
-	}, nil
+	p := &keyParser{section: section, problems: problems}
+	var logLevel zapcore.Level
+	if err := logLevel.UnmarshalText([]byte(p.string("log_level"))); err != nil {
+		problems.addf("log_level: %v, should be debug, info, warn or error", err)
+	}
+	lookupMode, err := chord.ParseLookupMode(p.string("lookup_mode"))
+	if err != nil {
+		problems.addf("lookup_mode: %v", err)
+	}
+	fingerRefresh, err := chord.ParseFingerRefresh(p.string("finger_refresh"))
+	if err != nil {
+		problems.addf("finger_refresh: %v", err)
+	}
+	// the successor list should be long enough to keep all the replicas reachable, when the replication is limited
+	maxReplication := p.int("max_replication", 0)
+	successorListSize := p.int("successor_list_size", chord.DEFAULT_SUCCESSOR_LIST_SIZE)
+	if successorListSize < maxReplication {
+		successorListSize = maxReplication
+	}
+	params := &Params{
+		Bootstrappers: p.list("bootstrapper"),
+		P2pAddress:    p.string("p2p_address"),
+		ApiAddress:    p.string("api_address"),
+		HttpAddress:   p.string("http_address"),
+		LogFile:       p.string("log_file"),
+		DataFile:      p.string("data_file"),
+		LogLevel:      logLevel,
+		CACert:        p.string("ca_cert"),
+		ServerCert:    p.string("hostcert"),
+		ServerKey:     p.string("hostkey"),
+		DrainTimeout:  p.duration("drain_timeout", DEFAULT_DRAIN_TIMEOUT),
+		ApiConfig: api.Config{
+			MaxConnections: p.int("max_connections", 1024),
+			MaxInFlight:    p.int("max_inflight_requests", 64),
+			RateLimit:      p.float("rate_limit", 0),
+			RateBurst:      p.int("rate_burst", 0),
+			MaxReplication: maxReplication,
+		},
+		ChordConfig: chord.Config{
+			LookupMode:         lookupMode,
+			VirtualNodes:       p.int("virtual_nodes", 1),
+			SuccessorListSize:  successorListSize,
+			FingerRefresh:      fingerRefresh,
+			PhiThreshold:       p.float("phi_threshold", chord.DEFAULT_PHI_THRESHOLD),
+			PingTimeout:        p.duration("ping_timeout", chord.DEFAULT_PING_TIMEOUT),
+			CheckInterval:      p.duration("check_interval", chord.DEFAULT_CHECK_INTERVAL),
+			StabilizeInterval:  p.duration("stabilize_interval", chord.DEFAULT_STABILIZE_INTERVAL),
+			FixFingersInterval: p.duration("fix_fingers_interval", chord.DEFAULT_FIX_FINGERS_INTERVAL),
+			MaxInterval:        p.duration("max_maintenance_interval", 2*time.Second),
+			Jitter:             p.float("maintenance_jitter", 0.1),
+			LookupTimeout:      p.duration("lookup_timeout", chord.DEFAULT_LOOKUP_TIMEOUT),
+			PutTimeout:         p.duration("put_timeout", chord.DEFAULT_PUT_TIMEOUT),
+			GetTimeout:         p.duration("get_timeout", chord.DEFAULT_GET_TIMEOUT),
+			JoinRetryInterval:  p.duration("join_retry_interval", chord.DEFAULT_JOIN_RETRY_INTERVAL),
+			JoinMaxInterval:    p.duration("join_max_interval", chord.DEFAULT_JOIN_MAX_INTERVAL),
+			JoinTimeout:        p.duration("join_timeout", chord.DEFAULT_JOIN_TIMEOUT),
+			RetryJoin:          p.bool("retry_join", false),
+		},
+		DiscoveryConfig: discovery.Config{
+			Group:    p.string("discovery_group"),
+			Cluster:  p.stringOr("cluster", discovery.DEFAULT_CLUSTER),
+			Interval: p.duration("discovery_interval", discovery.DEFAULT_INTERVAL),
+		},
+	}
+	params.validate(problems)
+	if err := problems.err(); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// restartKeys returns the keys of the parameters which differ between the old and the new parameters, but cannot
+// change without a restart.
+func restartKeys(old, new *Params) []string {
+	var keys []string
+	check := func(key string, changed bool) {
+		if changed {
+			keys = append(keys, key)
+		}
+	}
+	check("bootstrapper", strings.Join(old.Bootstrappers, ",") != strings.Join(new.Bootstrappers, ","))
+	check("p2p_address", old.P2pAddress != new.P2pAddress)
+	check("api_address", old.ApiAddress != new.ApiAddress)
+	check("http_address", old.HttpAddress != new.HttpAddress)
+	check("log_file", old.LogFile != new.LogFile)
+	check("data_file", old.DataFile != new.DataFile)
+	check("ca_cert", old.CACert != new.CACert)
+	check("hostcert", old.ServerCert != new.ServerCert)
+	check("hostkey", old.ServerKey != new.ServerKey)
+	check("max_replication", old.ApiConfig.MaxReplication != new.ApiConfig.MaxReplication)
+	check("virtual_nodes", old.ChordConfig.VirtualNodes != new.ChordConfig.VirtualNodes)
+	check("successor_list_size", old.ChordConfig.SuccessorListSize != new.ChordConfig.SuccessorListSize)
+	check("discovery_group", old.DiscoveryConfig.Group != new.DiscoveryConfig.Group)
+	check("cluster", old.DiscoveryConfig.Cluster != new.DiscoveryConfig.Cluster)
+	check("discovery_interval", old.DiscoveryConfig.Interval != new.DiscoveryConfig.Interval)
+	return keys
 }
 
-// Server defines a DHT server, consisting of Params, api.ApiServer, chord.P2pServer and storage.Storage.
+// Server defines a DHT server, consisting of Params, api.ApiServer, chord.P2pServer, storage.Storage and optionally
+// discovery.Discovery and metrics.Metrics.
 type Server struct {
-	Params    *Params
+	Params    *Params // the current parameters, replaced by Reload, use CurrentParams while Reload may run
 	ApiServer *api.ApiServer
 	P2pServer *chord.P2pServer
 	Storage   *storage.Storage
+	Discovery *discovery.Discovery // discovers the bootstrappers on the local network, nil if disabled
+	Metrics   *metrics.Metrics     // the metrics served on the HTTP address, nil if disabled
+
+	httpListener net.Listener            // the net.Listener of the HTTP address, nil if disabled
+	load         func() (*Params, error) // loads the parameters again on Reload, nil if created from Params
+	mutex        sync.Mutex
 }
 
 // NewServer creates a DHT server from the configuration file.
-func NewServer(configurationFile string) *Server {
-	params, err := readParams(configurationFile)
+func NewServer(configurationFile string) (*Server, error) {
+	return NewServerWithOverrides(configurationFile, nil)
+}
+
+// NewServerWithOverrides creates a DHT server from the configuration file overridden as LoadParams does. The
+// configuration file may be empty, if all the required keys are overridden. The same overrides apply on Reload.
+func NewServerWithOverrides(configurationFile string, overrides map[string]string) (*Server, error) {
+	load := func() (*Params, error) {
+		return LoadParams(configurationFile, overrides)
+	}
+	params, err := load()
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	// a standalone server owns the logger, which may have been initialized by a previous server in the process
+	if err := logger.Init(params.LogFile, params.LogLevel); err != nil {
+		return nil, fmt.Errorf("logger.Init error: %w", err)
 	}
-	fmt.Printf("%+v\n", params)
-	if !utils.Exists(params.CACert) || !utils.Exists(params.ServerCert) || !utils.Exists(params.ServerKey) {
-		log.Fatal("CACert, ServerCert or ServerKey doesn't exists")
+	server, err := NewServerFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+	server.load = load
+	return server, nil
+}
+
+// CurrentParams returns the current parameters of the server, which should not be modified.
+func (s *Server) CurrentParams() *Params {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.Params
+}
+
+// Reload loads the configuration file and the overrides again, and applies the parameters which can change at runtime, i.e. the log
+// level, the limits of the API clients, the Chord algorithm except its sizes, and the drain timeout. The changed
+// parameters which require a restart are kept as they are, and their keys are returned. An invalid configuration is
+// rejected as a whole, and the current parameters are kept.
+func (s *Server) Reload() ([]string, error) {
+	if s.load == nil {
+		return nil, errors.New("the server is not created from a configuration file")
+	}
+	params, err := s.load()
+	if err != nil {
+		logger.Logger.Errorw("reload rejected", "err", err)
+		return nil, fmt.Errorf("reload rejected: %w", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	old := s.Params
+	restart := restartKeys(old, params)
+	// keep the parameters which require a restart, so that Params always describes the running server
+	params.Bootstrappers, params.P2pAddress, params.ApiAddress = old.Bootstrappers, old.P2pAddress, old.ApiAddress
+	params.HttpAddress = old.HttpAddress
+	params.LogFile, params.DataFile = old.LogFile, old.DataFile
+	params.CACert, params.ServerCert, params.ServerKey = old.CACert, old.ServerCert, old.ServerKey
+	params.ApiConfig.MaxReplication = old.ApiConfig.MaxReplication
+	params.ChordConfig.VirtualNodes = old.ChordConfig.VirtualNodes
+	params.ChordConfig.SuccessorListSize = old.ChordConfig.SuccessorListSize
+	params.DiscoveryConfig = old.DiscoveryConfig
+
+	logger.SetLevel(params.LogLevel)
+	s.ApiServer.SetConfig(params.ApiConfig)
+	s.P2pServer.SetConfig(params.ChordConfig)
+	s.Params = params
+	logger.Logger.Infow("config reloaded", "params", params)
+	if len(restart) > 0 {
+		logger.Logger.Warnw("config changes require a restart", "keys", restart)
+	}
+	return restart, nil
+}
+
+// NewServerFromParams creates a DHT server from the given parameters. The logger is initialized with the LogFile and
+// the LogLevel unless it has been, e.g. by another server embedded in the process, whose log file is kept.
+func NewServerFromParams(params *Params) (*Server, error) {
+	var err error
+	if err := params.Validate(); err != nil {
+		return nil, err
 	}
 
-	if err := logger.Init(params.LogFile, zap.InfoLevel); err != nil {
-		log.Fatal("logger.Init error", err)
+	if err := logger.InitIfNeeded(params.LogFile, params.LogLevel); err != nil {
+		return nil, fmt.Errorf("logger.Init error: %w", err)
 	}
+	logger.Logger.Debugw("server params", "params", params)
 
 	server := &Server{
 		Params: params,
 	}
-	server.Storage = storage.NewStorage(params.DataFile)
-	server.P2pServer = chord.NewP2pServer(server.Storage, params.P2pAddress, params.CACert, params.ServerCert, params.ServerKey)
-	server.ApiServer = api.NewApiServer(server.P2pServer, params.ApiAddress)
-	return server
+	// the resources created so far are released in reverse order if the server fails to be created
+	var closers []func()
+	fail := func(err error) (*Server, error) {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i]()
+		}
+		return nil, err
+	}
+	if params.HttpAddress != "" {
+		if server.httpListener, err = net.Listen("tcp4", params.HttpAddress); err != nil {
+			return nil, fmt.Errorf("net.Listen error on http_address: %w", err)
+		}
+		closers = append(closers, func() { server.httpListener.Close() })
+		server.Metrics = metrics.New()
+	}
+	if params.DiscoveryConfig.Group != "" {
+		server.Discovery, err = discovery.New(params.DiscoveryConfig, params.P2pAddress, chord.NewNode(params.P2pAddress).Id)
+		if err != nil {
+			return fail(fmt.Errorf("discovery.New error: %w", err))
+		}
+		closers = append(closers, func() { server.Discovery.Close() })
+	}
+	if server.Storage, err = storage.NewStorage(params.DataFile); err != nil {
+		return fail(fmt.Errorf("storage.NewStorage error: %w", err))
+	}
+	closers = append(closers, func() { server.Storage.Close() })
+	server.P2pServer, err = chord.NewP2pServer(server.Storage, params.P2pAddress, params.CACert, params.ServerCert, params.ServerKey, params.ChordConfig, server.Metrics)
+	if err != nil {
+		return fail(fmt.Errorf("chord.NewP2pServer error: %w", err))
+	}
+	closers = append(closers, server.P2pServer.Close)
+	server.ApiServer, err = api.NewApiServer(server.P2pServer, params.ApiAddress, params.ApiConfig, server.Metrics)
+	if err != nil {
+		return fail(fmt.Errorf("api.NewApiServer error: %w", err))
+	}
+	if server.Discovery != nil {
+		server.P2pServer.Discoverer = server.Discovery
+	}
+	server.registerGauges()
+	return server, nil
+}
+
+// Close releases the addresses listened on and the data file of a Server which is never served, so that a new Server
+// of the same Params can be created. A Server served releases them once Serve returns.
+func (s *Server) Close() error {
+	s.ApiServer.Close()
+	s.P2pServer.Close()
+	if s.Discovery != nil {
+		s.Discovery.Close()
+	}
+	if s.httpListener != nil {
+		s.httpListener.Close()
+	}
+	return s.Storage.Close()
+}
+
+// registerGauges registers the gauges of the state of the server, which are read on each scrape.
+func (s *Server) registerGauges() {
+	if s.Metrics == nil {
+		return
+	}
+	s.Metrics.Gauge("stored_keys", "Keys in the local storage, including the expired ones not deleted yet.", func() float64 {
+		return float64(s.Storage.Len())
+	})
+	s.Metrics.Gauge("stored_bytes", "Bytes of the keys and the records in the local storage.", func() float64 {
+		return float64(s.Storage.Size())
+	})
+	s.Metrics.Gauge("ring_size_estimate", "Nodes in the ring including the virtual ones, estimated from the successor list.", func() float64 {
+		return s.P2pServer.RpcServer.RingSizeEstimate()
+	})
+	s.Metrics.Gauge("api_connections", "Current API client connections.", func() float64 {
+		return float64(s.ApiServer.NumConnections())
+	})
+	s.Metrics.Gauge("p2p_connections", "Current pooled connections to the other nodes.", func() float64 {
+		return float64(s.P2pServer.RpcServer.Pool.Len())
+	})
 }
 
-// Serve runs the DHT server, starting the API server and P2P server.
-func (s *Server) Serve() {
-	logger.Logger.Infow("Start Server", "params", s.Params)
+// Serve runs the DHT server, starting the API server and P2P server, until the given context is canceled or either
+// server fails. On shutdown, the API server drains the in-flight requests first, then the P2P server stops, and the
+// storage is closed at last.
+func (s *Server) Serve(ctx context.Context) error {
+	params := s.CurrentParams()
+	logger.Logger.Infow("Start Server", "params", params)
+	apiCtx, cancelApi := context.WithCancel(ctx)
+	defer cancelApi()
+	p2pCtx, cancelP2p := context.WithCancel(context.Background())
+	defer cancelP2p()
+
+	discoveryDone := make(chan struct{})
 	go func() {
-		fmt.Println("api.Serve")
-		if err := s.ApiServer.Serve(); err != nil {
-			log.Fatal("api.Serve failed", err)
+		defer close(discoveryDone)
+		if s.Discovery == nil {
+			return
+		}
+		// the nodes configured with bootstrappers can still be discovered by the others
+		if err := s.Discovery.Serve(p2pCtx); err != nil {
+			logger.Logger.Warnw("discovery.Serve failed", "err", err)
 		}
 	}()
 
-	fmt.Println("chord.Serve")
-	err := s.P2pServer.Serve(s.Params.Bootstrapper)
-	if err != nil {
-		log.Fatal("chord.Serve failed", err)
-	}
-}
+	httpDone := make(chan struct{})
+	go func() {
+		defer close(httpDone)
+		// the metrics and the status are kept available until the P2P server stops
+		if err := s.serveHttp(p2pCtx, ctx); err != nil {
+			logger.Logger.Warnw("http.Serve failed", "err", err)
+		}
+	}()
 
-// Stop stops the DHT server gracefully.
-func (s *Server) Stop() {
-	s.ApiServer.Stop()
-	s.P2pServer.Stop()
+	p2pErr := make(chan error, 1)
+	go func() {
+		logger.Logger.Debugw("chord.Serve")
+		err := s.P2pServer.Serve(p2pCtx, params.Bootstrappers)
+		// the API server is useless without the P2P server
+		cancelApi()
+		p2pErr <- err
+	}()
+
+	logger.Logger.Debugw("api.Serve")
+	apiErr := s.ApiServer.Serve(apiCtx)
+	cancelP2p()
+	err := <-p2pErr
+	<-discoveryDone
+	<-httpDone
+	closeErr := s.Storage.Close()
+	switch {
+	case apiErr != nil:
+		return fmt.Errorf("api.Serve failed: %w", apiErr)
+	case err != nil:
+		return fmt.Errorf("chord.Serve failed: %w", err)
+	case closeErr != nil:
+		return fmt.Errorf("storage.Close failed: %w", closeErr)
+	}
+	return nil
 }
@@ -3,65 +3,398 @@ package storage
 import (
 	"DHT/internal/logger"
 	"DHT/internal/utils"
+	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"github.com/tidwall/buntdb"
-	"log"
+	"strings"
+	"sync"
 	"time"
 )
 
 // Storage defines a persistent K/V storage.
 type Storage struct {
-	db *buntdb.DB
+	db            *buntdb.DB
+	listeners     []func(Event) // the listeners called on each change of keys
+	listenerMutex sync.RWMutex
+}
+
+// EventType defines the type of a change of a key.
+type EventType int32
+
+// Here defines some EventType constants for all types of changes.
+const (
+	EVENT_PUT    EventType = 0 // the key is put
+	EVENT_DELETE EventType = 1 // the key is deleted
+	EVENT_EXPIRE EventType = 2 // the key has expired
+)
+
+// Event defines a change of a key in the storage.
+type Event struct {
+	Type    EventType // the type of the change
+	Key     []byte    // the key changed
+	Value   []byte    // the value after a put, or the last value before a deletion or an expiration
+	Version uint64    // the version of the value
+}
+
+// record defines a data item persisted in the storage.
+type record struct {
+	Version     uint64 `json:"v"`           // the version of the item, incremented by one on each put
+	Value       []byte `json:"d"`           // the value of the item
+	Replication int32  `json:"r,omitempty"` // the number of replicas from this storage on along the successors, 0 if unknown
+}
+
+// Item defines a data item in the storage, returned by Items.
+type Item struct {
+	Key         []byte        // the key of the item
+	Value       []byte        // the value of the item
+	Version     uint64        // the version of the item
+	Replication int32         // the number of replicas from this storage on along the successors, 0 if unknown
+	TTL         time.Duration // the time before the item expires
+}
+
+// CondKind defines the kind of condition of a conditional put.
+type CondKind int32
+
+// Here defines some CondKind constants for all kinds of conditions.
+const (
+	COND_ABSENT  CondKind = 0 // the key must not exist
+	COND_VALUE   CondKind = 1 // the current value must equal the expected value
+	COND_VERSION CondKind = 2 // the current version must equal the expected version, 0 for a non-existing key
+)
+
+// Condition defines the condition of a conditional put.
+type Condition struct {
+	Kind    CondKind // the kind of the condition
+	Value   []byte   // the expected value, used by COND_VALUE
+	Version uint64   // the expected version, used by COND_VERSION
 }
 
 // NewStorage creates a K/V storage, persisting to the given data file.
-func NewStorage(dataFile string) *Storage {
+func NewStorage(dataFile string) (*Storage, error) {
 	const DATA_FOLDER string = "./data"
 	utils.CheckAndMakeDir(DATA_FOLDER)
-	if db, err := buntdb.Open(DATA_FOLDER + "/" + dataFile); err != nil {
-		log.Fatal(err)
+	db, err := buntdb.Open(DATA_FOLDER + "/" + dataFile)
+	if err != nil {
+		return nil, err
+	}
+	s := &Storage{db: db}
+	var config buntdb.Config
+	if err := db.ReadConfig(&config); err != nil {
+		db.Close()
+		return nil, err
+	}
+	config.OnExpiredSync = s.onExpired
+	if err := db.SetConfig(config); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the storage, flushing the data file.
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
+// AddListener adds a listener which is called on each change of keys. The listener is called synchronously, so that
+// it should never block.
+func (s *Storage) AddListener(listener func(Event)) {
+	s.listenerMutex.Lock()
+	defer s.listenerMutex.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
+// notify calls all the listeners with the given event.
+func (s *Storage) notify(ev Event) {
+	s.listenerMutex.RLock()
+	defer s.listenerMutex.RUnlock()
+	for _, listener := range s.listeners {
+		listener(ev)
+	}
+}
+
+// onExpired deletes an expired item inside the transaction of the background expiration, and notifies the listeners.
+func (s *Storage) onExpired(key, value string, tx *buntdb.Tx) error {
+	if _, err := tx.Delete(key); err != nil && err != buntdb.ErrNotFound {
+		return err
+	}
+	keyBytes, err := decodeBytes(key)
+	if err != nil {
 		return nil
-	} else {
-		return &Storage{db: db}
 	}
+	rec, err := decodeRecord(value)
+	if err != nil {
+		return nil
+	}
+	s.notify(Event{Type: EVENT_EXPIRE, Key: keyBytes, Value: rec.Value, Version: rec.Version})
+	return nil
 }
 
-// Put the key/value pair into the storage expiring in `ttl` seconds.
-func (s *Storage) Put(key []byte, value []byte, ttl time.Duration) {
+// Put the key/value pair into the storage expiring in `ttl` seconds, and returns the new version of the pair.
+func (s *Storage) Put(key []byte, value []byte, ttl time.Duration) (version uint64) {
 	logger.Logger.Infow("storage.Put", "key", string(key), "value", string(value), "ttl", ttl.Seconds())
+	return s.put(key, value, ttl, 0, 0)
+}
+
+// PutVersion puts the key/value pair of the given version into the storage expiring in `ttl` seconds,
+// used for replicas. The pair is ignored if a newer version is stored.
+func (s *Storage) PutVersion(key []byte, value []byte, ttl time.Duration, version uint64) {
+	logger.Logger.Infow("storage.PutVersion", "key", string(key), "value", string(value), "ttl", ttl.Seconds(), "version", version)
+	s.put(key, value, ttl, version, 0)
+}
+
+// PutReplica puts the key/value pair into the storage expiring in `ttl` seconds, together with the number of replicas
+// from this storage on. A new version is assigned if the given version is 0, otherwise the pair is ignored if a newer
+// version is stored. It returns the version of the pair.
+func (s *Storage) PutReplica(key []byte, value []byte, ttl time.Duration, version uint64, replication int32) uint64 {
+	logger.Logger.Infow("storage.PutReplica", "key", string(key), "value", string(value), "ttl", ttl.Seconds(), "version", version, "replication", replication)
+	return s.put(key, value, ttl, version, replication)
+}
+
+// put puts the key/value pair into the storage, assigning a new version if the given version is 0.
+func (s *Storage) put(key []byte, value []byte, ttl time.Duration, version uint64, replication int32) uint64 {
+	applied := false
+	err := s.db.Update(func(tx *buntdb.Tx) error {
+		old, _, err := getRecord(tx, encodeBytes(key))
+		if err != nil {
+			return err
+		}
+		if version == 0 {
+			version = old.Version + 1
+		} else if old.Version > version {
+			return nil
+		}
+		applied = true
+		return setRecord(tx, encodeBytes(key), &record{Version: version, Value: value, Replication: replication}, ttl)
+	})
+	if err != nil {
+		logger.Logger.Warnw("storage.put error", "err", err)
+		return 0
+	}
+	if applied {
+		s.notify(Event{Type: EVENT_PUT, Key: key, Value: value, Version: version})
+	}
+	return version
+}
+
+// PutIf puts the key/value pair into the storage expiring in `ttl` seconds only if the condition holds, atomically,
+// together with the number of replicas from this storage on as PutReplica does. It returns whether the pair is put,
+// and the version and the value stored after the operation.
+func (s *Storage) PutIf(key []byte, value []byte, ttl time.Duration, replication int32, cond Condition) (version uint64, current []byte, ok bool) {
+	defer func() {
+		logger.Logger.Infow("storage.PutIf", "key", string(key), "value", string(value), "ttl", ttl.Seconds(), "replication", replication, "cond", cond, "ok", ok)
+	}()
 	err := s.db.Update(func(tx *buntdb.Tx) error {
-		_, _, err := tx.Set(encodeBytes(key), encodeBytes(value), &buntdb.SetOptions{Expires: true, TTL: ttl})
+		old, exists, err := getRecord(tx, encodeBytes(key))
+		if err != nil {
+			return err
+		}
+		version, current = old.Version, old.Value
+		switch cond.Kind {
+		case COND_ABSENT:
+			ok = !exists
+		case COND_VALUE:
+			ok = exists && bytes.Equal(old.Value, cond.Value)
+		case COND_VERSION:
+			ok = old.Version == cond.Version
+		}
+		if !ok {
+			return nil
+		}
+		version, current = old.Version+1, value
+		return setRecord(tx, encodeBytes(key), &record{Version: version, Value: value, Replication: replication}, ttl)
+	})
+	if err != nil {
+		logger.Logger.Warnw("storage.PutIf error", "err", err)
+		return 0, nil, false
+	}
+	if ok {
+		s.notify(Event{Type: EVENT_PUT, Key: key, Value: value, Version: version})
+	}
+	return version, current, ok
+}
+
+// Touch extends the expiry of the key to `ttl` from now without changing its value, and returns whether the key exists.
+func (s *Storage) Touch(key []byte, ttl time.Duration) (ok bool) {
+	logger.Logger.Infow("storage.Touch", "key", string(key), "ttl", ttl.Seconds())
+	err := s.db.Update(func(tx *buntdb.Tx) error {
+		val, err := tx.Get(encodeBytes(key))
+		if err == buntdb.ErrNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		ok = true
+		_, _, err = tx.Set(encodeBytes(key), val, &buntdb.SetOptions{Expires: true, TTL: ttl})
 		return err
 	})
 	if err != nil {
-		logger.Logger.Warnw("storage.Put error", "err", err)
+		logger.Logger.Warnw("storage.Touch error", "err", err)
+		return false
+	}
+	return ok
+}
+
+// Delete deletes the key from the storage, and returns whether the key existed.
+func (s *Storage) Delete(key []byte) bool {
+	logger.Logger.Infow("storage.Delete", "key", string(key))
+	var old *record
+	var ok bool
+	err := s.db.Update(func(tx *buntdb.Tx) (err error) {
+		old, ok, err = getRecord(tx, encodeBytes(key))
+		if err != nil || !ok {
+			return err
+		}
+		_, err = tx.Delete(encodeBytes(key))
+		return err
+	})
+	if err != nil {
+		logger.Logger.Warnw("storage.Delete error", "err", err)
+		return false
+	}
+	if ok {
+		s.notify(Event{Type: EVENT_DELETE, Key: key, Value: old.Value, Version: old.Version})
 	}
+	return ok
 }
 
 // Get finds the value for the given key, if any.
 func (s *Storage) Get(key []byte) (valBytes []byte, ok bool) {
-	defer logger.Logger.Infow("storage.Get", "key", string(key), "value", string(valBytes), "ok", ok)
-	var val string
+	valBytes, _, ok = s.GetVersion(key)
+	return valBytes, ok
+}
+
+// GetVersion finds the value and its version for the given key, if any.
+func (s *Storage) GetVersion(key []byte) (valBytes []byte, version uint64, ok bool) {
+	defer func() {
+		logger.Logger.Infow("storage.Get", "key", string(key), "value", string(valBytes), "version", version, "ok", ok)
+	}()
+	var rec *record
 	err := s.db.View(func(tx *buntdb.Tx) (err error) {
-		val, err = tx.Get(encodeBytes(key))
-		if err != nil {
-			return err
-		}
+		rec, ok, err = getRecord(tx, encodeBytes(key))
+		return err
+	})
+	if err != nil {
+		logger.Logger.Warnw("storage.Get error", "err", err)
+		return nil, 0, false
+	}
+	if !ok {
+		return nil, 0, false
+	}
+	return rec.Value, rec.Version, true
+}
+
+// Items returns all the data items in the storage.
+func (s *Storage) Items() []*Item {
+	var items []*Item
+	err := s.db.View(func(tx *buntdb.Tx) error {
+		var err error
+		tx.Ascend("", func(key, value string) bool {
+			var keyBytes []byte
+			var rec *record
+			var ttl time.Duration
+			if keyBytes, err = decodeBytes(key); err != nil {
+				return false
+			}
+			if rec, err = decodeRecord(value); err != nil {
+				return false
+			}
+			if ttl, err = tx.TTL(key); err == buntdb.ErrNotFound {
+				// expired but not deleted yet
+				err = nil
+				return true
+			} else if err != nil {
+				return false
+			}
+			items = append(items, &Item{Key: keyBytes, Value: rec.Value, Version: rec.Version, Replication: rec.Replication, TTL: ttl})
+			return true
+		})
+		return err
+	})
+	if err != nil {
+		logger.Logger.Warnw("storage.Items error", "err", err)
 		return nil
+	}
+	return items
+}
+
+// Len returns the number of the keys in the storage, including the expired ones not deleted yet.
+func (s *Storage) Len() int {
+	var n int
+	err := s.db.View(func(tx *buntdb.Tx) error {
+		var err error
+		n, err = tx.Len()
+		return err
 	})
-	if err == nil {
-		if data, err := decodeBytes(val); err != nil {
-			logger.Logger.Warnw("storage.Get error", "err", err)
-			return nil, false
-		} else {
-			return data, true
+	if err != nil {
+		logger.Logger.Warnw("storage.Len error", "err", err)
+	}
+	return n
+}
+
+// Size returns the total bytes of the keys and the records in the storage as stored, which scans all the keys.
+func (s *Storage) Size() int64 {
+	var size int64
+	err := s.db.View(func(tx *buntdb.Tx) error {
+		return tx.Ascend("", func(key, value string) bool {
+			size += int64(len(key) + len(value))
+			return true
+		})
+	})
+	if err != nil {
+		logger.Logger.Warnw("storage.Size error", "err", err)
+	}
+	return size
+}
+
+// getRecord gets the record of the given encoded key in the transaction, an empty record is returned if not exists.
+func getRecord(tx *buntdb.Tx, key string) (*record, bool, error) {
+	val, err := tx.Get(key)
+	if err == buntdb.ErrNotFound {
+		return &record{}, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	rec, err := decodeRecord(val)
+	if err != nil {
+		return nil, false, err
+	}
+	return rec, true, nil
+}
+
+// setRecord sets the record of the given encoded key in the transaction, expiring in `ttl`.
+func setRecord(tx *buntdb.Tx, key string, rec *record, ttl time.Duration) error {
+	val, err := encodeRecord(rec)
+	if err != nil {
+		return err
+	}
+	_, _, err = tx.Set(key, val, &buntdb.SetOptions{Expires: true, TTL: ttl})
+	return err
+}
+
+// encodeRecord encodes the record to a string.
+func encodeRecord(rec *record) (string, error) {
+	data, err := json.Marshal(rec)
+	return string(data), err
+}
+
+// decodeRecord decodes the record from a string, supporting the values persisted without versions as well.
+func decodeRecord(s string) (*record, error) {
+	if !strings.HasPrefix(s, "{") {
+		data, err := decodeBytes(s)
+		if err != nil {
+			return nil, err
 		}
+		return &record{Value: data}, nil
+	}
+	rec := &record{}
+	if err := json.Unmarshal([]byte(s), rec); err != nil {
+		return nil, err
 	}
-	//if err == buntdb.ErrNotFound {
-	//	return nil, false
-	//}
-	//logger.Logger.Warnw("storage.Get error", "err", err)
-	return nil, false
+	return rec, nil
 }
 
 // encodeBytes encodes the data of []byte to a string.
@@ -3,6 +3,7 @@ package utils
 import (
 	"bytes"
 	"crypto/sha1"
+	"fmt"
 	"log"
 	"os"
 )
@@ -29,9 +30,17 @@ func SHA1(data []byte) []byte {
 	return sum[:]
 }
 
+// NodeId computes the id of the given virtual node of the server at the address, the virtual node 0 is identified by the address only.
+func NodeId(address string, vnode int32) []byte {
+	if vnode == 0 {
+		return SHA1([]byte(address))
+	}
+	return SHA1([]byte(fmt.Sprintf("%s#%d", address, vnode)))
+}
+
 // CheckIdentity checks whether the identity is valid
-func CheckIdentity(id []byte, address string) bool {
-	return bytes.Equal(id, SHA1([]byte(address)))
+func CheckIdentity(id []byte, address string, vnode int32) bool {
+	return bytes.Equal(id, NodeId(address, vnode))
 }
 
 // IsInRange returns whether c in the range (l,r].
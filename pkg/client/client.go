@@ -7,14 +7,26 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"net"
+	"time"
 )
 
 // Client defines a API client connecting to a API server representing the Chord network.
 type Client struct {
-	Address string
-	conn    net.Conn
-	reader  *bufio.Reader
+	Address       string
+	conn          net.Conn
+	reader        *bufio.Reader
+	notifications []*Notification // the notifications received while waiting for responses
+}
+
+// Notification defines a change of a subscribed key pushed by the server.
+type Notification struct {
+	Type    uint8  // the type of the change, one of api.NOTIFY_PUT, api.NOTIFY_DELETE and api.NOTIFY_EXPIRE
+	Version uint64 // the version of the value
+	Key     []byte // the key changed
+	Value   []byte // the value after a put, or the last value before a deletion or an expiration
+	Omitted bool   // whether the value is omitted by the server due to the message size limit
 }
 
 // NewClient creates a client connecting to the given API server address.
@@ -30,19 +42,24 @@ func NewClient(address string) *Client {
 	return c
 }
 
-// sendGetMessage sends a GET message to the server.
-func (c *Client) sendGetMessage(key []byte) error {
-	buf := new(bytes.Buffer)
-	binary.Write(buf, binary.BigEndian, uint16((32+256)/8))  // size
-	binary.Write(buf, binary.BigEndian, uint16(api.DHT_GET)) // DHT GET
-	if len(key) < 32 {                                       // key
+// writeKey writes the key to the buffer, padded with zeros or truncated to api.KEY_SIZE bytes.
+func writeKey(buf *bytes.Buffer, key []byte) {
+	if len(key) < api.KEY_SIZE {
 		buf.Write(key)
-		for i := 0; i < 32-len(key); i++ {
+		for i := 0; i < api.KEY_SIZE-len(key); i++ {
 			buf.WriteByte(0)
 		}
 	} else {
-		buf.Write(key[:32])
+		buf.Write(key[:api.KEY_SIZE])
 	}
+}
+
+// sendGetMessage sends a GET message to the server.
+func (c *Client) sendGetMessage(key []byte) error {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, uint16((32+256)/8))  // size
+	binary.Write(buf, binary.BigEndian, uint16(api.DHT_GET)) // DHT GET
+	writeKey(buf, key)                                       // key
 	_, err := c.conn.Write(buf.Bytes())
 	if err != nil {
 		return err
@@ -58,15 +75,8 @@ func (c *Client) sendPutMessage(key []byte, value []byte, ttl uint16, replicatio
 	binary.Write(buf, binary.BigEndian, uint16(ttl))                   // ttl
 	binary.Write(buf, binary.BigEndian, uint8(replication))            // replication
 	binary.Write(buf, binary.BigEndian, uint8(0))                      // reserved
-	if len(key) < 32 {                                                 // key
-		buf.Write(key)
-		for i := 0; i < 32-len(key); i++ {
-			buf.WriteByte(0)
-		}
-	} else {
-		buf.Write(key[:32])
-	}
-	buf.Write(value) // value
+	writeKey(buf, key)                                                 // key
+	buf.Write(value)                                                   // value
 
 	_, err := c.conn.Write(buf.Bytes())
 	if err != nil {
@@ -75,25 +85,94 @@ func (c *Client) sendPutMessage(key []byte, value []byte, ttl uint16, replicatio
 	return nil
 }
 
-// receiveMessage receives a DHT_SUCCESS or DHT_FAILURE message from the server.
-func (c *Client) receiveMessage() ([]byte, bool, error) {
-	data := make([]byte, 4+32)
-	if n, err := c.reader.Read(data); err != nil || n != 4+32 {
-		return nil, false, errors.New("message length error")
+// RequestError defines an error telling that a request was rejected by the server.
+type RequestError struct {
+	MsgType api.MsgType // the message type of the rejected request, 0 if the connection was rejected
+	Code    api.ErrCode // the reason of the rejection
+}
+
+// Error returns a string describing the RequestError.
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("request %v rejected, code %v", e.MsgType, e.Code)
+}
+
+// readMessage reads a whole message from the server, return its message type in api.MsgType and message body in []byte.
+func (c *Client) readMessage() (api.MsgType, []byte, error) {
+	data := make([]byte, 4)
+	if _, err := io.ReadFull(c.reader, data); err != nil {
+		return 0, nil, errors.New("message length error")
 	}
 	size := binary.BigEndian.Uint16(data[0:2])
 	msgType := binary.BigEndian.Uint16(data[2:4])
-	if api.MsgType(msgType) == api.DHT_FAILURE {
+	if size < 4 {
+		return 0, nil, errors.New("message length error")
+	}
+	data = make([]byte, size-4)
+	if _, err := io.ReadFull(c.reader, data); err != nil {
+		return 0, nil, errors.New("message length error")
+	}
+	return api.MsgType(msgType), data, nil
+}
+
+// parseNotification parses the body of a DHT_NOTIFICATION message.
+func parseNotification(body []byte) (*Notification, error) {
+	if len(body) < 12+api.KEY_SIZE {
+		return nil, errors.New("message length error")
+	}
+	return &Notification{
+		Type:    body[0],
+		Version: binary.BigEndian.Uint64(body[4:12]),
+		Key:     body[12 : 12+api.KEY_SIZE],
+		Value:   body[12+api.KEY_SIZE:],
+		Omitted: body[1]&api.NOTIFY_OMITTED != 0,
+	}, nil
+}
+
+// receiveMessage receives the response message of a request of the given message type from the server,
+// skipping rejections of requests which have no response, e.g. DHT_PUT, and queueing the notifications.
+func (c *Client) receiveMessage(reqType api.MsgType) (api.MsgType, []byte, error) {
+	for {
+		msgType, body, err := c.readMessage()
+		if err != nil {
+			return 0, nil, err
+		}
+		if msgType == api.DHT_NOTIFICATION {
+			n, err := parseNotification(body)
+			if err != nil {
+				return 0, nil, err
+			}
+			c.notifications = append(c.notifications, n)
+			continue
+		}
+		if msgType != api.DHT_ERROR {
+			return msgType, body, nil
+		}
+		if len(body) < 4 {
+			return 0, nil, errors.New("message length error")
+		}
+		e := &RequestError{
+			MsgType: api.MsgType(binary.BigEndian.Uint16(body[0:2])),
+			Code:    api.ErrCode(binary.BigEndian.Uint16(body[2:4])),
+		}
+		if e.MsgType == reqType || e.MsgType == 0 {
+			return 0, nil, e
+		}
+	}
+}
+
+// receiveGetResponse receives a DHT_SUCCESS or DHT_FAILURE message from the server.
+func (c *Client) receiveGetResponse() ([]byte, bool, error) {
+	msgType, body, err := c.receiveMessage(api.DHT_GET)
+	if err != nil {
+		return nil, false, err
+	}
+	if msgType == api.DHT_FAILURE {
 		return nil, false, nil
 	}
-	if api.MsgType(msgType) != api.DHT_SUCCESS {
+	if msgType != api.DHT_SUCCESS || len(body) < 32 {
 		return nil, false, errors.New("message error")
 	}
-	data = make([]byte, size-4-32)
-	if n, err := c.reader.Read(data); err != nil || n != len(data) {
-		return nil, false, errors.New("message length error")
-	}
-	return data, true, nil
+	return body[32:], true, nil
 }
 
 // Get retrieves the value for the key from the server.
@@ -101,7 +180,7 @@ func (c *Client) Get(key []byte) ([]byte, bool, error) {
 	if err := c.sendGetMessage(key); err != nil {
 		return nil, false, err
 	}
-	return c.receiveMessage()
+	return c.receiveGetResponse()
 }
 
 // Put ask the server to store the key/value pair to the Chord network.
@@ -112,6 +191,301 @@ func (c *Client) Put(key []byte, value []byte, ttl uint16, replication uint8) {
 	}
 }
 
+// Touch asks the server to extend the expiry of the key and its replicas to `ttl` seconds from now, and returns
+// whether the key exists.
+func (c *Client) Touch(key []byte, ttl uint16, replication uint8) (bool, error) {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, uint16(8+api.KEY_SIZE)) // size
+	binary.Write(buf, binary.BigEndian, uint16(api.DHT_TOUCH))  // DHT TOUCH
+	binary.Write(buf, binary.BigEndian, uint16(ttl))            // ttl
+	binary.Write(buf, binary.BigEndian, uint8(replication))     // replication
+	binary.Write(buf, binary.BigEndian, uint8(0))               // reserved
+	writeKey(buf, key)                                          // key
+	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+		return false, err
+	}
+	msgType, _, err := c.receiveMessage(api.DHT_TOUCH)
+	if err != nil {
+		return false, err
+	}
+	if msgType != api.DHT_SUCCESS && msgType != api.DHT_FAILURE {
+		return false, errors.New("message error")
+	}
+	return msgType == api.DHT_SUCCESS, nil
+}
+
+// TraceHop defines a node visited by a lookup.
+type TraceHop struct {
+	Id      []byte        // the id of the node
+	Addr    string        // the P2P address of the node
+	Latency time.Duration // the latency of the hop, excluding the time spent by the hops after it
+	Failed  bool          // whether the node was unresponsive and skipped
+}
+
+// Trace defines the result of a traced lookup.
+type Trace struct {
+	SuccessorId   []byte     // the id of the node responsible for the key
+	SuccessorAddr string     // the P2P address of the node responsible for the key
+	Hops          []TraceHop // the nodes visited by the lookup, in order
+}
+
+// Trace asks the server to look up the node responsible for the key, and returns the nodes visited.
+func (c *Client) Trace(key []byte) (*Trace, error) {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, uint16(4+api.KEY_SIZE)) // size
+	binary.Write(buf, binary.BigEndian, uint16(api.DHT_TRACE))  // DHT TRACE
+	writeKey(buf, key)                                          // key
+	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+		return nil, err
+	}
+	msgType, body, err := c.receiveMessage(api.DHT_TRACE)
+	if err != nil {
+		return nil, err
+	}
+	if msgType == api.DHT_FAILURE {
+		return nil, errors.New("lookup failed")
+	}
+	const ID_SIZE = 20
+	if msgType != api.DHT_TRACE_RESULT || len(body) < api.KEY_SIZE+ID_SIZE+4 {
+		return nil, errors.New("message error")
+	}
+	data := body[api.KEY_SIZE:]
+	size := int(binary.BigEndian.Uint16(data[ID_SIZE : ID_SIZE+2]))
+	count := int(binary.BigEndian.Uint16(data[ID_SIZE+2 : ID_SIZE+4]))
+	if len(data) < ID_SIZE+4+size {
+		return nil, errors.New("message length error")
+	}
+	trace := &Trace{SuccessorId: data[:ID_SIZE], SuccessorAddr: string(data[ID_SIZE+4 : ID_SIZE+4+size])}
+	data = data[ID_SIZE+4+size:]
+	for i := 0; i < count; i++ {
+		if len(data) < 8+ID_SIZE {
+			return nil, errors.New("message length error")
+		}
+		size := int(binary.BigEndian.Uint16(data[6:8]))
+		if len(data) < 8+ID_SIZE+size {
+			return nil, errors.New("message length error")
+		}
+		trace.Hops = append(trace.Hops, TraceHop{
+			Id:      data[8 : 8+ID_SIZE],
+			Addr:    string(data[8+ID_SIZE : 8+ID_SIZE+size]),
+			Latency: time.Duration(binary.BigEndian.Uint32(data[0:4])) * time.Microsecond,
+			Failed:  data[4] == 1,
+		})
+		data = data[8+ID_SIZE+size:]
+	}
+	return trace, nil
+}
+
+// BatchGet retrieves the values for all the keys from the server, and returns whether each key is found.
+// The value of a key is nil and not found if it is omitted by the server due to the message size limit.
+func (c *Client) BatchGet(keys [][]byte) ([][]byte, []bool, error) {
+	if len(keys) > api.MAX_BATCH_GET_KEYS {
+		return nil, nil, errors.New("too many keys")
+	}
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, uint16(8+len(keys)*api.KEY_SIZE)) // size
+	binary.Write(buf, binary.BigEndian, uint16(api.DHT_BATCH_GET))        // DHT BATCH GET
+	binary.Write(buf, binary.BigEndian, uint16(len(keys)))                // count
+	binary.Write(buf, binary.BigEndian, uint16(0))                        // reserved
+	for _, key := range keys {
+		writeKey(buf, key)
+	}
+	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+		return nil, nil, err
+	}
+
+	msgType, body, err := c.receiveMessage(api.DHT_BATCH_GET)
+	if err != nil {
+		return nil, nil, err
+	}
+	if msgType != api.DHT_BATCH_GET_RESULT || len(body) < 4 || int(binary.BigEndian.Uint16(body[0:2])) != len(keys) {
+		return nil, nil, errors.New("message error")
+	}
+	values := make([][]byte, len(keys))
+	oks := make([]bool, len(keys))
+	data := body[4:]
+	for i := range keys {
+		if len(data) < api.KEY_SIZE+4 {
+			return nil, nil, errors.New("message length error")
+		}
+		size := int(binary.BigEndian.Uint16(data[api.KEY_SIZE+2 : api.KEY_SIZE+4]))
+		if len(data) < api.KEY_SIZE+4+size {
+			return nil, nil, errors.New("message length error")
+		}
+		if data[api.KEY_SIZE] == api.BATCH_SUCCESS {
+			values[i] = data[api.KEY_SIZE+4 : api.KEY_SIZE+4+size]
+			oks[i] = true
+		}
+		data = data[api.KEY_SIZE+4+size:]
+	}
+	return values, oks, nil
+}
+
+// BatchPut asks the server to store all the key/value pairs to the Chord network, and returns whether each pair is stored.
+func (c *Client) BatchPut(keys [][]byte, values [][]byte, ttl uint16, replication uint8) ([]bool, error) {
+	if len(keys) != len(values) {
+		return nil, errors.New("number of keys and values mismatch")
+	}
+	size := 12
+	for _, value := range values {
+		size += api.KEY_SIZE + 2 + len(value)
+	}
+	if size > api.MAX_MSG_SIZE {
+		return nil, errors.New("too many key/value pairs")
+	}
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, uint16(size))              // size
+	binary.Write(buf, binary.BigEndian, uint16(api.DHT_BATCH_PUT)) // DHT BATCH PUT
+	binary.Write(buf, binary.BigEndian, uint16(ttl))               // ttl
+	binary.Write(buf, binary.BigEndian, uint8(replication))        // replication
+	binary.Write(buf, binary.BigEndian, uint8(0))                  // reserved
+	binary.Write(buf, binary.BigEndian, uint16(len(keys)))         // count
+	binary.Write(buf, binary.BigEndian, uint16(0))                 // reserved
+	for i, key := range keys {
+		writeKey(buf, key)
+		binary.Write(buf, binary.BigEndian, uint16(len(values[i])))
+		buf.Write(values[i])
+	}
+	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+		return nil, err
+	}
+
+	msgType, body, err := c.receiveMessage(api.DHT_BATCH_PUT)
+	if err != nil {
+		return nil, err
+	}
+	if msgType != api.DHT_BATCH_PUT_RESULT || len(body) != 4+len(keys)*(api.KEY_SIZE+2) {
+		return nil, errors.New("message error")
+	}
+	oks := make([]bool, len(keys))
+	for i := range keys {
+		oks[i] = body[4+i*(api.KEY_SIZE+2)+api.KEY_SIZE] == api.BATCH_SUCCESS
+	}
+	return oks, nil
+}
+
+// sendCondMessage sends a DHT_PUT_IF_ABSENT or a DHT_CAS message to the server, in which `mode` and `cond` are the
+// mode and the condition part of the DHT_CAS message.
+func (c *Client) sendCondMessage(msgType api.MsgType, key []byte, value []byte, ttl uint16, replication uint8, mode uint8, cond []byte) error {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, uint16(4+4+api.KEY_SIZE+len(cond)+len(value))) // size
+	binary.Write(buf, binary.BigEndian, uint16(msgType))                               // DHT PUT IF ABSENT or DHT CAS
+	binary.Write(buf, binary.BigEndian, uint16(ttl))                                   // ttl
+	binary.Write(buf, binary.BigEndian, uint8(replication))                            // replication
+	binary.Write(buf, binary.BigEndian, uint8(mode))                                   // mode, reserved for DHT PUT IF ABSENT
+	writeKey(buf, key)                                                                 // key
+	buf.Write(cond)                                                                    // condition
+	buf.Write(value)                                                                   // value
+	if buf.Len() > api.MAX_MSG_SIZE {
+		return errors.New("value too large")
+	}
+	_, err := c.conn.Write(buf.Bytes())
+	return err
+}
+
+// receiveCondResponse receives a DHT_COND_SUCCESS or DHT_COND_FAILURE message from the server, returns whether the
+// pair is put, and the version and the value stored after the operation.
+func (c *Client) receiveCondResponse(reqType api.MsgType) (bool, uint64, []byte, error) {
+	msgType, body, err := c.receiveMessage(reqType)
+	if err != nil {
+		return false, 0, nil, err
+	}
+	if msgType == api.DHT_FAILURE {
+		return false, 0, nil, errors.New("request failed")
+	}
+	if (msgType != api.DHT_COND_SUCCESS && msgType != api.DHT_COND_FAILURE) || len(body) < api.KEY_SIZE+8 {
+		return false, 0, nil, errors.New("message error")
+	}
+	version := binary.BigEndian.Uint64(body[api.KEY_SIZE : api.KEY_SIZE+8])
+	if msgType == api.DHT_COND_FAILURE {
+		return false, version, body[api.KEY_SIZE+8:], nil
+	}
+	return true, version, nil, nil
+}
+
+// PutIfAbsent asks the server to store the key/value pair only if the key does not exist.
+// It returns whether the pair is stored, and the version of the key after the operation, as well as the current
+// value if the pair is not stored.
+func (c *Client) PutIfAbsent(key []byte, value []byte, ttl uint16, replication uint8) (bool, uint64, []byte, error) {
+	if err := c.sendCondMessage(api.DHT_PUT_IF_ABSENT, key, value, ttl, replication, 0, nil); err != nil {
+		return false, 0, nil, err
+	}
+	return c.receiveCondResponse(api.DHT_PUT_IF_ABSENT)
+}
+
+// CompareAndSwap asks the server to store the key/value pair only if the current value of the key equals `expected`.
+// It returns the same as PutIfAbsent.
+func (c *Client) CompareAndSwap(key []byte, expected []byte, value []byte, ttl uint16, replication uint8) (bool, uint64, []byte, error) {
+	cond := make([]byte, 4+len(expected))
+	binary.BigEndian.PutUint16(cond[0:2], uint16(len(expected)))
+	copy(cond[4:], expected)
+	if err := c.sendCondMessage(api.DHT_CAS, key, value, ttl, replication, api.CAS_BY_VALUE, cond); err != nil {
+		return false, 0, nil, err
+	}
+	return c.receiveCondResponse(api.DHT_CAS)
+}
+
+// CompareAndSwapVersion asks the server to store the key/value pair only if the current version of the key equals
+// `version`, in which version 0 stands for a non-existing key. It returns the same as PutIfAbsent.
+func (c *Client) CompareAndSwapVersion(key []byte, version uint64, value []byte, ttl uint16, replication uint8) (bool, uint64, []byte, error) {
+	cond := make([]byte, 8)
+	binary.BigEndian.PutUint64(cond, version)
+	if err := c.sendCondMessage(api.DHT_CAS, key, value, ttl, replication, api.CAS_BY_VERSION, cond); err != nil {
+		return false, 0, nil, err
+	}
+	return c.receiveCondResponse(api.DHT_CAS)
+}
+
+// sendSubscription sends a DHT_SUBSCRIBE or DHT_UNSUBSCRIBE message to the server, and waits for its response.
+func (c *Client) sendSubscription(msgType api.MsgType, key []byte) error {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, uint16(4+api.KEY_SIZE)) // size
+	binary.Write(buf, binary.BigEndian, uint16(msgType))        // DHT SUBSCRIBE or DHT UNSUBSCRIBE
+	writeKey(buf, key)                                          // key
+	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	respType, _, err := c.receiveMessage(msgType)
+	if err != nil {
+		return err
+	}
+	if respType != api.DHT_SUCCESS {
+		return errors.New("message error")
+	}
+	return nil
+}
+
+// Subscribe asks the server to push the changes of the key, which can be received by ReceiveNotification.
+func (c *Client) Subscribe(key []byte) error {
+	return c.sendSubscription(api.DHT_SUBSCRIBE, key)
+}
+
+// Unsubscribe asks the server to stop pushing the changes of the key.
+func (c *Client) Unsubscribe(key []byte) error {
+	return c.sendSubscription(api.DHT_UNSUBSCRIBE, key)
+}
+
+// ReceiveNotification waits for the next change of the subscribed keys.
+func (c *Client) ReceiveNotification() (*Notification, error) {
+	for len(c.notifications) == 0 {
+		msgType, body, err := c.readMessage()
+		if err != nil {
+			return nil, err
+		}
+		if msgType != api.DHT_NOTIFICATION {
+			continue
+		}
+		n, err := parseNotification(body)
+		if err != nil {
+			return nil, err
+		}
+		c.notifications = append(c.notifications, n)
+	}
+	n := c.notifications[0]
+	c.notifications = c.notifications[1:]
+	return n, nil
+}
+
 // Close closes the connection.
 func (c *Client) Close() {
 	c.conn.Close()
@@ -0,0 +1,182 @@
+package dht
+
+import (
+	"DHT/internal/api"
+	"DHT/internal/chord"
+	"DHT/internal/service"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Here defines the defaults of the Config.
+const (
+	DEFAULT_DATA_FILE       = "dht.db"  // default name of the data file under ./data
+	DEFAULT_LOG_FILE        = "dht.log" // default name of the log file under ./logs
+	DEFAULT_REPLICATION     = 2         // default number of replicas of each key put
+	DEFAULT_MAX_REPLICATION = 3         // default max number of replicas of each key
+)
+
+// Config defines the configuration of a Node. The zero values of the optional fields mean the defaults.
+type Config struct {
+	P2pAddress    string   // the address listened on for the other nodes, of format ip:port
+	ApiAddress    string   // the address listened on for the API clients, of format ip:port
+	Bootstrappers []string // the P2P addresses of the nodes in the existing network tried in order, empty to create a new network
+	CACert        string   // the path of the certificate of the CA
+	ServerCert    string   // the path of the certificate of this node, signed by the CA
+	ServerKey     string   // the path of the private key of this node
+	DataFile      string   // optional, the name of the data file under ./data
+	LogFile       string   // optional, the name of the log file under ./logs, ignored if another Node in the process has opened one
+	HttpAddress   string   // optional, the address serving the metrics and the admin endpoints over HTTP, of format ip:port
+
+	LookupMode     string        // optional, the mode of the lookups, recursive or iterative
+	VirtualNodes   int           // optional, the number of virtual nodes, proportional to the capacity of this node
+	Replication    int           // optional, the number of replicas of each key put by this node
+	MaxReplication int           // optional, the max number of replicas of each key, as well as the successor list size
+	LookupTimeout  time.Duration // optional, the deadline of each lookup
+	PutTimeout     time.Duration // optional, the deadline of each put or delete
+	GetTimeout     time.Duration // optional, the deadline of each get
+	JoinTimeout    time.Duration // optional, the time spent on joining through the Bootstrappers before giving up
+	RetryJoin      bool          // optional, whether to run alone rather than fail once JoinTimeout is exceeded, retrying to join in background
+}
+
+// params returns the service.Params of the configuration, with the defaults filled in.
+func (c Config) params() (*service.Params, error) {
+	if c.P2pAddress == "" || c.ApiAddress == "" {
+		return nil, errors.New("P2pAddress and ApiAddress are required")
+	}
+	if c.DataFile == "" {
+		c.DataFile = DEFAULT_DATA_FILE
+	}
+	if c.LogFile == "" {
+		c.LogFile = DEFAULT_LOG_FILE
+	}
+	if c.MaxReplication <= 0 {
+		c.MaxReplication = DEFAULT_MAX_REPLICATION
+	}
+	if c.Replication <= 0 {
+		c.Replication = DEFAULT_REPLICATION
+	}
+	if c.VirtualNodes <= 0 {
+		c.VirtualNodes = 1
+	}
+	if c.Replication > c.MaxReplication || c.MaxReplication > math.MaxUint8 {
+		return nil, fmt.Errorf("Replication %d should be in [1, MaxReplication %d], which is at most %d", c.Replication, c.MaxReplication, math.MaxUint8)
+	}
+	lookupMode, err := chord.ParseLookupMode(c.LookupMode)
+	if err != nil {
+		return nil, err
+	}
+	return &service.Params{
+		Bootstrappers: c.Bootstrappers,
+		ApiAddress:    c.ApiAddress,
+		P2pAddress:    c.P2pAddress,
+		HttpAddress:   c.HttpAddress,
+		LogFile:       c.LogFile,
+		DataFile:      c.DataFile,
+		CACert:        c.CACert,
+		ServerCert:    c.ServerCert,
+		ServerKey:     c.ServerKey,
+		ApiConfig: api.Config{
+			MaxReplication: c.MaxReplication,
+		},
+		ChordConfig: chord.Config{
+			LookupMode:        lookupMode,
+			VirtualNodes:      c.VirtualNodes,
+			SuccessorListSize: c.MaxReplication,
+			MaxInterval:       2 * time.Second,
+			Jitter:            0.1,
+			LookupTimeout:     c.LookupTimeout,
+			PutTimeout:        c.PutTimeout,
+			GetTimeout:        c.GetTimeout,
+			JoinTimeout:       c.JoinTimeout,
+			RetryJoin:         c.RetryJoin,
+		},
+	}, nil
+}
+
+// Node defines a DHT node running in-process, which serves the other nodes and the API clients as a standalone
+// node does, and can be accessed directly by Put, Get and Delete without going through the API server.
+type Node struct {
+	server      *service.Server
+	replication uint8 // the number of replicas of each key put
+	deletion    uint8 // the number of replicas of each key deleted, i.e. the max replication
+
+	mutex   sync.Mutex
+	cancel  context.CancelFunc // stops the running node, nil if not started
+	errs    chan error         // receives the error of the node once stopped
+	stopped bool               // whether Stop has been called
+}
+
+// NewNode creates a Node of the given configuration, which listens on the addresses and opens the data file, but
+// doesn't serve until Start is called. The Node should be stopped by Stop whether started or not.
+func NewNode(cfg Config) (*Node, error) {
+	params, err := cfg.params()
+	if err != nil {
+		return nil, err
+	}
+	server, err := service.NewServerFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+	return &Node{
+		server:      server,
+		replication: uint8(cfg.Replication),
+		deletion:    uint8(params.ApiConfig.MaxReplication),
+	}, nil
+}
+
+// Start runs the node in background, joining the network through the Bootstrappers if any. A stopped node cannot be
+// started again, but a new Node of the same Config can.
+func (n *Node) Start() error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if n.errs != nil || n.stopped {
+		return errors.New("the node has been started or stopped")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	n.cancel, n.errs = cancel, make(chan error, 1)
+	go func() {
+		n.errs <- n.server.Serve(ctx)
+	}()
+	return nil
+}
+
+// Stop stops the running node gracefully, and returns the error which the node has failed with, if any. A node never
+// started releases its addresses and data file at once, so that Stop can always be deferred after NewNode.
+func (n *Node) Stop() error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if n.stopped {
+		return errors.New("the node has been stopped")
+	}
+	n.stopped = true
+	if n.cancel == nil {
+		return n.server.Close()
+	}
+	n.cancel()
+	n.cancel = nil
+	return <-n.errs
+}
+
+// Put puts the key/value pair into the network expiring in `ttl`, replicated for the configured Replication times.
+func (n *Node) Put(key []byte, value []byte, ttl time.Duration) error {
+	seconds := ttl / time.Second
+	if seconds <= 0 || seconds > math.MaxUint16 {
+		return fmt.Errorf("ttl %v should be in [1s, %ds]", ttl, math.MaxUint16)
+	}
+	return n.server.ApiServer.Put(key, value, uint16(seconds), n.replication)
+}
+
+// Get finds the value for the given key in the network, if any.
+func (n *Node) Get(key []byte) (value []byte, ok bool, err error) {
+	return n.server.ApiServer.Get(key)
+}
+
+// Delete deletes the key and all its replicas from the network, and returns whether the key existed.
+func (n *Node) Delete(key []byte) (ok bool, err error) {
+	return n.server.ApiServer.Delete(key, n.deletion)
+}
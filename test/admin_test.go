@@ -0,0 +1,90 @@
+package test
+
+import (
+	"DHT/internal/chord"
+	"DHT/internal/service"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// getJson gets the given URL, decodes its JSON body into v, and returns the status code.
+func getJson(t *testing.T, url string, v interface{}) int {
+	resp, err := http.Get(url)
+	if !assert.Nil(t, err) {
+		return 0
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(v))
+	return resp.StatusCode
+}
+
+func TestAdminEndpoints(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	// the node is alive but not ready while its bootstrapper is not started yet
+	_, stopLate := startServer(t, 63, map[string]string{
+		"http_address":        "127.0.0.1:7633",
+		"bootstrapper":        "127.0.0.1:7642",
+		"join_retry_interval": "100ms",
+		"join_max_interval":   "200ms",
+	})
+	time.Sleep(500 * time.Millisecond)
+	var health map[string]string
+	assert.Equal(t, http.StatusOK, getJson(t, "http://127.0.0.1:7633/healthz", &health))
+	assert.Equal(t, "ok", health["status"])
+	var ready map[string]interface{}
+	assert.Equal(t, http.StatusServiceUnavailable, getJson(t, "http://127.0.0.1:7633/readyz", &ready))
+	assert.Equal(t, false, ready["ready"])
+	assert.Equal(t, "not joined yet", ready["reason"])
+
+	// both nodes are ready once joined and stabilized
+	_, stopSeed := startServer(t, 64, map[string]string{"http_address": "127.0.0.1:7643"})
+	time.Sleep(3 * time.Second)
+	for _, address := range []string{"127.0.0.1:7633", "127.0.0.1:7643"} {
+		ready = nil
+		assert.Equal(t, http.StatusOK, getJson(t, "http://"+address+"/readyz", &ready))
+		assert.Equal(t, true, ready["ready"])
+	}
+
+	var status service.Status
+	assert.Equal(t, http.StatusOK, getJson(t, "http://127.0.0.1:7633/status", &status))
+	assert.True(t, status.Ready)
+	if assert.Len(t, status.Nodes, 1) {
+		node := status.Nodes[0]
+		assert.Equal(t, "127.0.0.1:7632", node.Self.Addr)
+		assert.Equal(t, "127.0.0.1:7642", node.Predecessor.Addr)
+		assert.Len(t, node.Fingers, chord.M)
+		assert.Equal(t, "127.0.0.1:7642", node.Fingers[0].Node.Addr)
+		assert.Equal(t, "127.0.0.1:7642", node.SuccessorList[0].Addr)
+		assert.Equal(t, "127.0.0.1:7642", node.PredecessorList[0].Addr)
+	}
+	assert.Equal(t, 0, status.Store.Keys)
+
+	assert.Nil(t, stopLate())
+	assert.Nil(t, stopSeed())
+
+	// a node running as a lone ring, as its bootstrapper does not answer, is not ready either
+	_, stopLone := startServer(t, 62, map[string]string{
+		"http_address":        "127.0.0.1:7623",
+		"bootstrapper":        "127.0.0.1:7592",
+		"retry_join":          "true",
+		"join_retry_interval": "100ms",
+		"join_max_interval":   "200ms",
+		"join_timeout":        "300ms",
+	})
+	time.Sleep(2 * time.Second)
+	ready = nil
+	assert.Equal(t, http.StatusServiceUnavailable, getJson(t, "http://127.0.0.1:7623/readyz", &ready))
+	assert.Equal(t, false, ready["ready"])
+	assert.Equal(t, "not joined yet", ready["reason"])
+	assert.Nil(t, stopLone())
+}
@@ -0,0 +1,76 @@
+package test
+
+import (
+	"DHT/internal/service"
+	"DHT/internal/utils"
+	"DHT/pkg/client"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestChurnWhileLookingUp(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	seed, stopSeed := startServer(t, 77, nil)
+	time.Sleep(500 * time.Millisecond)
+	bootstrapper := map[string]string{"bootstrapper": seed.Params.P2pAddress}
+	stable, stopStable := startServer(t, 78, bootstrapper)
+	servers := []*service.Server{seed, stable}
+	assert.Empty(t, waitRing(servers, 10*time.Second))
+
+	// lookups and puts keep running on the stable servers while the maintenance tasks update the ring state, checked
+	// by go test -race
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func(server *service.Server) {
+			defer wg.Done()
+			c := client.NewClient(server.Params.ApiAddress)
+			assert.NotNil(t, c)
+			defer c.Close()
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				key := []byte(fmt.Sprintf("churn-%d", i))
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				// a lookup may fail while a node is leaving
+				_, _ = server.P2pServer.RpcServer.Lookup(ctx, utils.SHA1(key))
+				cancel()
+				c.Put(key, key, 5, 2)
+				_ = server.Status(context.Background())
+			}
+		}(server)
+	}
+
+	// a node keeps joining and leaving through the seed meanwhile
+	for round := 0; round < 3; round++ {
+		_, stop := startServer(t, 79, bootstrapper)
+		time.Sleep(time.Second)
+		assert.Nil(t, stop())
+	}
+	close(done)
+	wg.Wait()
+
+	// the stable servers form a consistent ring again, and serve the lookups
+	assert.Empty(t, waitRing(servers, 10*time.Second))
+	for _, server := range servers {
+		node, err := server.P2pServer.RpcServer.Lookup(context.Background(), utils.SHA1([]byte("churn")))
+		assert.Nil(t, err)
+		assert.NotNil(t, node)
+	}
+	assert.Nil(t, stopStable())
+	assert.Nil(t, stopSeed())
+}
@@ -0,0 +1,53 @@
+package test
+
+import (
+	"DHT/internal/service"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadParams(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	params, err := service.LoadParams("./config/node1/config1.ini", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "127.0.0.1:7412", params.P2pAddress)
+	assert.Equal(t, 0, params.ApiConfig.MaxReplication)
+
+	// the environment variables override the file, and the overrides override both
+	t.Setenv("DHT_API_ADDRESS", "127.0.0.1:7481")
+	t.Setenv("DHT_CHECK_INTERVAL", "200ms")
+	params, err = service.LoadParams("./config/node1/config1.ini", map[string]string{"api_address": "127.0.0.1:7482"})
+	assert.Nil(t, err)
+	assert.Equal(t, "127.0.0.1:7482", params.ApiAddress)
+	assert.Equal(t, 200*time.Millisecond, params.ChordConfig.CheckInterval)
+	assert.Contains(t, params.INI(), "check_interval = 200ms\n")
+
+	// a successor list too short to reach all the replicas is raised rather than rejected
+	params, err = service.LoadParams("./config/node1/config1.ini", map[string]string{"successor_list_size": "1", "max_replication": "4"})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, params.ChordConfig.SuccessorListSize)
+
+	// all the problems are reported at once, each naming the key to fix
+	file := filepath.Join(t.TempDir(), "invalid.ini")
+	content := "[dht]\np2p_address = 127.0.0.1\nlog_file = node.log\ndata_file = data.db\n" +
+		"ca_cert = ./config/ca-cert/ca-cert.pem\nhostkey = ./config/node1/hostkey.pem\nhostcert = ./missing.pem\n" +
+		"max_conections = 10\nping_timeout = 1\nmaintenance_jitter = 2\n"
+	assert.Nil(t, os.WriteFile(file, []byte(content), 0644))
+	_, err = service.LoadParams(file, map[string]string{"virtual_nodes": "0"})
+	var configErr *service.ConfigError
+	assert.True(t, errors.As(err, &configErr))
+	for _, key := range []string{"p2p_address", "hostcert", "max_conections", "ping_timeout", "maintenance_jitter", "virtual_nodes"} {
+		assert.True(t, strings.Contains(err.Error(), key+": "), "missing problem of %s in %v", key, err)
+	}
+	// the api_address missing in the file is still taken from the environment
+	assert.False(t, strings.Contains(err.Error(), "api_address: "), "unexpected problem of api_address in %v", err)
+}
@@ -0,0 +1,40 @@
+package test
+
+import (
+	"DHT/internal/service"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCreateServerReleasesAddresses(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	overrides := map[string]string{
+		"p2p_address":  "127.0.0.1:7722",
+		"api_address":  "127.0.0.1:7721",
+		"http_address": "127.0.0.1:7723",
+		"log_file":     "join.log",
+		"data_file":    "data-join72.db",
+		"ca_cert":      "./config/ca-cert/ca-cert.pem",
+		"hostkey":      "./config/node0/hostkey.pem",
+		"hostcert":     "./config/node0/hostcert.pem",
+	}
+	// the API address is taken, so the server fails after the other addresses and the data file are taken
+	l, err := net.Listen("tcp4", overrides["api_address"])
+	assert.Nil(t, err)
+	_, err = service.NewServerWithOverrides("", overrides)
+	assert.NotNil(t, err)
+	assert.Nil(t, l.Close())
+
+	// all of them are released, so that the server can be created again in the same process
+	server, err := service.NewServerWithOverrides("", overrides)
+	if assert.Nil(t, err) {
+		server.Close()
+	}
+}
@@ -0,0 +1,64 @@
+package test
+
+import (
+	"DHT/internal/chord"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// heartbeats records n heartbeats of the node on the detector, one every interval.
+func heartbeats(d *chord.FailureDetector, node *chord.Node, n int, interval time.Duration) {
+	for i := 0; i < n; i++ {
+		time.Sleep(interval)
+		d.Heartbeat(node, time.Millisecond)
+	}
+}
+
+func TestFailureDetectorPhi(t *testing.T) {
+	d := &chord.FailureDetector{}
+	node := chord.NewNode("127.0.0.1:7811")
+	// a peer first seen is not suspected, the given interval being assumed
+	assert.Less(t, d.Phi(node, 50*time.Millisecond), 1.0)
+
+	// the heartbeats arrive on time
+	heartbeats(d, node, 10, 50*time.Millisecond)
+	normal := d.Phi(node, 50*time.Millisecond)
+	assert.Less(t, normal, 1.0)
+
+	// a late heartbeat raises the suspicion, but not up to the default threshold
+	time.Sleep(250 * time.Millisecond)
+	late := d.Phi(node, 50*time.Millisecond)
+	assert.Greater(t, late, normal)
+	assert.Less(t, late, chord.DEFAULT_PHI_THRESHOLD)
+
+	// the missing heartbeats make it dead
+	time.Sleep(time.Second)
+	missing := d.Phi(node, 50*time.Millisecond)
+	assert.Greater(t, missing, chord.DEFAULT_PHI_THRESHOLD)
+
+	// a new heartbeat clears the suspicion
+	d.Heartbeat(node, time.Millisecond)
+	assert.Less(t, d.Phi(node, 50*time.Millisecond), 1.0)
+}
+
+func TestFailureDetectorRetain(t *testing.T) {
+	d := &chord.FailureDetector{}
+	successor := chord.NewNode("127.0.0.1:7812")
+	vnode := chord.NewVirtualNode("127.0.0.1:7812", 1)
+	predecessor := chord.NewNode("127.0.0.1:7813")
+	for _, node := range []*chord.Node{successor, vnode, predecessor} {
+		d.Heartbeat(node, 10*time.Millisecond)
+	}
+	assert.Equal(t, 10*time.Millisecond, d.Rtt(successor))
+	assert.Equal(t, 3, d.Len())
+
+	// the virtual nodes of a server are watched apart, and only the given ones are kept
+	d.Retain(successor, nil)
+	assert.Equal(t, 1, d.Len())
+	assert.Equal(t, 10*time.Millisecond, d.Rtt(successor))
+	assert.Equal(t, time.Duration(0), d.Rtt(predecessor))
+
+	d.Forget(successor)
+	assert.Equal(t, 0, d.Len())
+}
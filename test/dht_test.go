@@ -0,0 +1,88 @@
+package test
+
+import (
+	"DHT/pkg/dht"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDhtNode(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	node, err := dht.NewNode(dht.Config{
+		P2pAddress: "127.0.0.1:7452",
+		ApiAddress: "127.0.0.1:7451",
+		CACert:     "./config/ca-cert/ca-cert.pem",
+		ServerCert: "./config/node0/hostcert.pem",
+		ServerKey:  "./config/node0/hostkey.pem",
+		DataFile:   "data-embedded.db",
+		LogFile:    "embedded.log",
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, node.Start())
+	assert.NotNil(t, node.Start())
+
+	key := []byte("embedded")
+	assert.Nil(t, node.Put(key, []byte("value"), 10*time.Second))
+	v, ok, err := node.Get(key)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value"), v)
+
+	ok, err = node.Delete(key)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	_, ok, err = node.Get(key)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.NotNil(t, node.Put(key, []byte("value"), 0))
+
+	assert.Nil(t, node.Stop())
+	assert.NotNil(t, node.Stop())
+
+	// the addresses and the data file are released once stopped
+	node, err = dht.NewNode(dht.Config{
+		P2pAddress: "127.0.0.1:7452",
+		ApiAddress: "127.0.0.1:7451",
+		CACert:     "./config/ca-cert/ca-cert.pem",
+		ServerCert: "./config/node0/hostcert.pem",
+		ServerKey:  "./config/node0/hostkey.pem",
+		DataFile:   "data-embedded.db",
+		LogFile:    "embedded.log",
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, node.Start())
+	assert.Nil(t, node.Stop())
+
+	_, err = dht.NewNode(dht.Config{ApiAddress: "127.0.0.1:7451"})
+	assert.NotNil(t, err)
+
+	// a node never started releases the addresses and the data file on Stop, and a later node of another log file
+	// doesn't take over the log file of the process
+	os.Remove("./logs/embedded-other.log")
+	cfg := dht.Config{
+		P2pAddress: "127.0.0.1:7452",
+		ApiAddress: "127.0.0.1:7451",
+		CACert:     "./config/ca-cert/ca-cert.pem",
+		ServerCert: "./config/node0/hostcert.pem",
+		ServerKey:  "./config/node0/hostkey.pem",
+		DataFile:   "data-embedded.db",
+		LogFile:    "embedded-other.log",
+	}
+	for i := 0; i < 2; i++ {
+		node, err = dht.NewNode(cfg)
+		if assert.Nil(t, err) {
+			assert.Nil(t, node.Stop())
+			assert.NotNil(t, node.Stop())
+			assert.NotNil(t, node.Start())
+		}
+	}
+	_, err = os.Stat("./logs/embedded-other.log")
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,39 @@
+package test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiscovery(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	discovery := func(cluster string) map[string]string {
+		return map[string]string{
+			"discovery_group":    "239.255.74.0:7400",
+			"cluster":            cluster,
+			"discovery_interval": "200ms",
+		}
+	}
+
+	// the first node discovers nothing and creates a ring, which the later node of the same cluster joins
+	seed, stopSeed := startServer(t, 54, discovery("test"))
+	time.Sleep(time.Second)
+	joiner, stopJoiner := startServer(t, 55, discovery("test"))
+	other, stopOther := startServer(t, 56, discovery("other"))
+	time.Sleep(3 * time.Second)
+	assert.Equal(t, seed.P2pServer.RpcServer.Self.Addr, joiner.P2pServer.RpcServer.Successor().Addr)
+	assert.Equal(t, joiner.P2pServer.RpcServer.Self.Addr, seed.P2pServer.RpcServer.Successor().Addr)
+	// the node of another cluster stays alone
+	assert.Equal(t, other.P2pServer.RpcServer.Self.Addr, other.P2pServer.RpcServer.Successor().Addr)
+
+	assert.Nil(t, stopOther())
+	assert.Nil(t, stopJoiner())
+	assert.Nil(t, stopSeed())
+}
@@ -0,0 +1,74 @@
+package test
+
+import (
+	"DHT/internal/chord"
+	"DHT/internal/service"
+	"DHT/internal/utils"
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fingerProblems returns the Finger table entries of all the virtual nodes of the servers which are not the successor
+// of their start.
+func fingerProblems(servers []*service.Server) []string {
+	var problems []string
+	for _, server := range servers {
+		for _, vnode := range server.P2pServer.VirtualServers {
+			for i := 0; i < chord.M; i++ {
+				finger := vnode.Finger(i)
+				expected := expectedSuccessor(servers, utils.AddBytesPower2(vnode.Self.Id, i))
+				if finger == nil || !bytes.Equal(finger.Id, expected.Id) || finger.Ident() != expected.Ident() {
+					problems = append(problems, fmt.Sprintf("%s finger %d is %s, want %s", vnode.Self.Ident(), i, finger.ToString(), expected.ToString()))
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// waitFingers waits up to the given timeout for the Finger tables of the servers to be correct, and returns the
+// problems left.
+func waitFingers(servers []*service.Server, timeout time.Duration) []string {
+	deadline := time.Now().Add(timeout)
+	for {
+		problems := fingerProblems(servers)
+		if len(problems) == 0 || time.Now().After(deadline) {
+			return problems
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+func TestFullFingerRefresh(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	overrides := map[string]string{"finger_refresh": "full", "virtual_nodes": "2"}
+	first, stopFirst := startServer(t, 91, overrides)
+	time.Sleep(1500 * time.Millisecond)
+	overrides["bootstrapper"] = first.Params.P2pAddress
+	second, stopSecond := startServer(t, 92, overrides)
+	third, stopThird := startServer(t, 93, overrides)
+	servers := []*service.Server{first, second, third}
+	assert.Empty(t, waitRing(servers, 15*time.Second))
+
+	// the whole Finger table is refreshed in each round, so all the entries are correct soon after the ring is
+	// consistent
+	assert.Empty(t, waitFingers(servers, 5*time.Second))
+
+	// the entries also catch up with a node leaving
+	assert.Nil(t, stopThird())
+	servers = servers[:2]
+	assert.Empty(t, waitRing(servers, 15*time.Second))
+	assert.Empty(t, waitFingers(servers, 5*time.Second))
+
+	assert.Nil(t, stopSecond())
+	assert.Nil(t, stopFirst())
+}
@@ -0,0 +1,43 @@
+package test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJoinBootstrappers(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	retry := map[string]string{
+		"join_retry_interval": "100ms",
+		"join_max_interval":   "300ms",
+		"join_timeout":        "500ms",
+	}
+
+	// without retry_join, the server fails once no bootstrapper answers within join_timeout
+	retry["bootstrapper"] = "127.0.0.1:7492"
+	_, stop := startServer(t, 48, retry)
+	time.Sleep(2 * time.Second)
+	assert.NotNil(t, stop())
+
+	// with retry_join, the server runs as a lone ring, and joins once any of its bootstrappers is started
+	retry["bootstrapper"] = "127.0.0.1:7482, 127.0.0.1:7492"
+	retry["retry_join"] = "true"
+	lone, stopLone := startServer(t, 47, retry)
+	time.Sleep(time.Second)
+	assert.Equal(t, lone.P2pServer.RpcServer.Self.Addr, lone.P2pServer.RpcServer.Successor().Addr)
+
+	seed, stopSeed := startServer(t, 49, nil)
+	time.Sleep(3 * time.Second)
+	assert.Equal(t, seed.P2pServer.RpcServer.Self.Addr, lone.P2pServer.RpcServer.Successor().Addr)
+	assert.Equal(t, lone.P2pServer.RpcServer.Self.Addr, seed.P2pServer.RpcServer.Successor().Addr)
+
+	assert.Nil(t, stopLone())
+	assert.Nil(t, stopSeed())
+}
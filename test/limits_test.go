@@ -0,0 +1,161 @@
+package test
+
+import (
+	"DHT/internal/api"
+	"DHT/internal/service"
+	"DHT/pkg/client"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startServer creates a server of the given two-digit index from a configuration file holding the overrides, and runs
+// it in background, returning the function stopping it.
+func startServer(t *testing.T, i int, overrides map[string]string) (*service.Server, func() error) {
+	params := map[string]string{
+		"p2p_address": fmt.Sprintf("127.0.0.1:7%d2", i),
+		"api_address": fmt.Sprintf("127.0.0.1:7%d1", i),
+		"log_file":    "test.log",
+		"data_file":   fmt.Sprintf("data-test%d.db", i),
+		"ca_cert":     "./config/ca-cert/ca-cert.pem",
+		"hostkey":     "./config/node0/hostkey.pem",
+		"hostcert":    "./config/node0/hostcert.pem",
+	}
+	for key, value := range overrides {
+		params[key] = value
+	}
+	lines := []string{"[dht]"}
+	for key, value := range params {
+		lines = append(lines, key+" = "+value)
+	}
+	file := filepath.Join(t.TempDir(), "config.ini")
+	assert.Nil(t, os.WriteFile(file, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+	server, err := service.NewServer(file)
+	assert.Nil(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve(ctx)
+	}()
+	return server, func() error {
+		cancel()
+		return <-errs
+	}
+}
+
+// assertRejected asserts that the error tells the request of the given message type is rejected for the given reason.
+func assertRejected(t *testing.T, msgType api.MsgType, code api.ErrCode, err error) {
+	var e *client.RequestError
+	if assert.True(t, errors.As(err, &e), "%v is not a RequestError", err) {
+		assert.Equal(t, msgType, e.MsgType)
+		assert.Equal(t, code, e.Code)
+	}
+}
+
+// pipelineGets sends n DHT_GET messages at once over a new connection to the address, and returns the error code of
+// each response, 0 if the request is answered.
+func pipelineGets(t *testing.T, address string, n int) []api.ErrCode {
+	conn, err := net.Dial("tcp", address)
+	if !assert.Nil(t, err) {
+		return nil
+	}
+	defer conn.Close()
+	buf := new(bytes.Buffer)
+	for i := 0; i < n; i++ {
+		binary.Write(buf, binary.BigEndian, uint16(4+api.KEY_SIZE))
+		binary.Write(buf, binary.BigEndian, uint16(api.DHT_GET))
+		buf.Write(make([]byte, api.KEY_SIZE))
+	}
+	_, err = conn.Write(buf.Bytes())
+	assert.Nil(t, err)
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	codes := make([]api.ErrCode, n)
+	for i := range codes {
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(conn, header); !assert.Nil(t, err) {
+			return codes
+		}
+		body := make([]byte, binary.BigEndian.Uint16(header[0:2])-4)
+		if _, err := io.ReadFull(conn, body); !assert.Nil(t, err) {
+			return codes
+		}
+		if api.MsgType(binary.BigEndian.Uint16(header[2:4])) == api.DHT_ERROR {
+			assert.Equal(t, api.DHT_GET, api.MsgType(binary.BigEndian.Uint16(body[0:2])))
+			codes[i] = api.ErrCode(binary.BigEndian.Uint16(body[2:4]))
+		}
+	}
+	return codes
+}
+
+func TestApiLimits(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	server, stop := startServer(t, 96, map[string]string{"max_connections": "2", "max_inflight_requests": "1", "max_replication": "2"})
+	time.Sleep(1500 * time.Millisecond)
+	address := server.Params.ApiAddress
+
+	// the connections beyond max_connections are rejected
+	first, second := client.NewClient(address), client.NewClient(address)
+	time.Sleep(200 * time.Millisecond)
+	third := client.NewClient(address)
+	if assert.NotNil(t, third) {
+		_, _, err := third.Get([]byte("limited"))
+		assertRejected(t, 0, api.ERR_TOO_MANY_CONNECTIONS, err)
+		third.Close()
+	}
+	_, _, err := first.Get([]byte("limited"))
+	assert.Nil(t, err)
+	// the replication beyond max_replication is rejected
+	_, err = first.Touch([]byte("limited"), 60, 3)
+	assertRejected(t, api.DHT_TOUCH, api.ERR_REPLICATION_TOO_MANY, err)
+	first.Close()
+	second.Close()
+	time.Sleep(200 * time.Millisecond)
+
+	// the requests sent while max_inflight_requests are in flight are rejected, the others are answered
+	codes := pipelineGets(t, address, 50)
+	rejected := 0
+	for _, code := range codes {
+		if code != 0 {
+			assert.Equal(t, api.ERR_TOO_MANY_REQUESTS, code)
+			rejected++
+		}
+	}
+	assert.Greater(t, rejected, 0)
+	assert.Less(t, rejected, len(codes))
+	assert.Nil(t, stop())
+
+	// the requests beyond rate_burst are rejected, until the tokens are refilled at rate_limit
+	server, stop = startServer(t, 90, map[string]string{"rate_limit": "2", "rate_burst": "5"})
+	time.Sleep(1500 * time.Millisecond)
+	c := client.NewClient(server.Params.ApiAddress)
+	if assert.NotNil(t, c) {
+		for i := 0; i < 5; i++ {
+			_, _, err := c.Get([]byte("limited"))
+			assert.Nil(t, err)
+		}
+		_, _, err := c.Get([]byte("limited"))
+		assertRejected(t, api.DHT_GET, api.ERR_RATE_LIMITED, err)
+		time.Sleep(time.Second)
+		_, _, err = c.Get([]byte("limited"))
+		assert.Nil(t, err)
+		// any replication is accepted unless max_replication is set
+		_, err = c.Touch([]byte("limited"), 60, 10)
+		assert.Nil(t, err)
+		c.Close()
+	}
+	assert.Nil(t, stop())
+}
@@ -0,0 +1,115 @@
+package test
+
+import (
+	"DHT/internal/chord"
+	"DHT/internal/service"
+	"DHT/internal/utils"
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ringProblems returns the problems of the ring formed by all the virtual nodes of the given servers, i.e. each
+// virtual node whose successor or predecessor is not the next or the previous one in the order of the ids.
+func ringProblems(servers []*service.Server) []string {
+	var vnodes []*chord.ChordRpcServer
+	for _, server := range servers {
+		vnodes = append(vnodes, server.P2pServer.VirtualServers...)
+	}
+	sort.Slice(vnodes, func(i, j int) bool {
+		return bytes.Compare(vnodes[i].Self.Id, vnodes[j].Self.Id) < 0
+	})
+	var problems []string
+	for i, vnode := range vnodes {
+		next, prev := vnodes[(i+1)%len(vnodes)].Self, vnodes[(i+len(vnodes)-1)%len(vnodes)].Self
+		if suc := vnode.Successor(); suc == nil || !bytes.Equal(suc.Id, next.Id) || suc.Ident() != next.Ident() {
+			problems = append(problems, fmt.Sprintf("%s successor is %s, want %s", vnode.Self.Ident(), suc.ToString(), next.ToString()))
+		}
+		if pred := vnode.Predecessor(); pred == nil || !bytes.Equal(pred.Id, prev.Id) || pred.Ident() != prev.Ident() {
+			problems = append(problems, fmt.Sprintf("%s predecessor is %s, want %s", vnode.Self.Ident(), pred.ToString(), prev.ToString()))
+		}
+	}
+	return problems
+}
+
+// waitRing waits up to the given timeout for the ring of the servers to be consistent, and returns the problems left.
+func waitRing(servers []*service.Server, timeout time.Duration) []string {
+	deadline := time.Now().Add(timeout)
+	for {
+		problems := ringProblems(servers)
+		if len(problems) == 0 || time.Now().After(deadline) {
+			return problems
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// expectedSuccessor returns the virtual node of the servers which is the successor of the given id.
+func expectedSuccessor(servers []*service.Server, id []byte) *chord.Node {
+	var nodes []*chord.Node
+	for _, server := range servers {
+		for _, vnode := range server.P2pServer.VirtualServers {
+			nodes = append(nodes, vnode.Self)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return bytes.Compare(nodes[i].Id, nodes[j].Id) < 0
+	})
+	for _, node := range nodes {
+		if bytes.Compare(node.Id, id) >= 0 {
+			return node
+		}
+	}
+	return nodes[0]
+}
+
+func TestLookupModes(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	first, stopFirst := startServer(t, 84, nil)
+	time.Sleep(1500 * time.Millisecond)
+	// the later servers join by iterative and recursive lookups respectively
+	second, stopSecond := startServer(t, 85, map[string]string{"bootstrapper": first.Params.P2pAddress, "lookup_mode": "iterative"})
+	third, stopThird := startServer(t, 86, map[string]string{"bootstrapper": first.Params.P2pAddress, "lookup_mode": "recursive"})
+	servers := []*service.Server{first, second, third}
+	assert.Empty(t, waitRing(servers, 15*time.Second))
+	assert.Equal(t, chord.LOOKUP_ITERATIVE, second.P2pServer.RpcServer.Config().LookupMode)
+	assert.Equal(t, chord.LOOKUP_RECURSIVE, third.P2pServer.RpcServer.Config().LookupMode)
+
+	// the lookups find the same successors in both modes, switched while running
+	server := first.P2pServer.RpcServer
+	for _, mode := range []chord.LookupMode{chord.LOOKUP_ITERATIVE, chord.LOOKUP_RECURSIVE, chord.LOOKUP_ITERATIVE} {
+		config := server.Config()
+		config.LookupMode = mode
+		server.SetConfig(config)
+		assert.Equal(t, mode, server.Config().LookupMode)
+		lookups := server.LookupStats().Lookups
+		for i := 0; i < 20; i++ {
+			id := utils.SHA1([]byte(fmt.Sprintf("mode-%d", i)))
+			expected := expectedSuccessor(servers, id)
+			trace, err := server.LookupTrace(context.Background(), id)
+			if assert.Nil(t, err, "lookup in mode %v", mode) {
+				assert.Equal(t, expected.Id, trace.GetSuccessor().GetId(), "lookup in mode %v", mode)
+				assert.Equal(t, expected.Addr, trace.GetSuccessor().GetAddr(), "lookup in mode %v", mode)
+				for _, hop := range trace.GetHops() {
+					assert.False(t, hop.GetFailed())
+				}
+			}
+		}
+		// the maintenance tasks look up as well
+		assert.GreaterOrEqual(t, server.LookupStats().Lookups, lookups+20)
+	}
+
+	assert.Nil(t, stopThird())
+	assert.Nil(t, stopSecond())
+	assert.Nil(t, stopFirst())
+}
@@ -0,0 +1,62 @@
+package test
+
+import (
+	"DHT/internal/service"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// lookups returns the total number of the lookups initiated by the servers.
+func lookups(servers []*service.Server) uint64 {
+	total := uint64(0)
+	for _, server := range servers {
+		total += server.P2pServer.RpcServer.LookupStats().Lookups
+	}
+	return total
+}
+
+// lookupRate returns the number of the lookups per second initiated by the servers, i.e. by their FixFingers, over
+// the given duration. The fingers spanning half of the ring at most, a node only looks up the ones beyond its successor.
+func lookupRate(servers []*service.Server, d time.Duration) float64 {
+	before := lookups(servers)
+	time.Sleep(d)
+	return float64(lookups(servers)-before) / d.Seconds()
+}
+
+func TestMaintenanceBackoff(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	overrides := map[string]string{
+		"fix_fingers_interval":     "20ms",
+		"max_maintenance_interval": "320ms",
+		"maintenance_jitter":       "0",
+	}
+	first, stopFirst := startServer(t, 87, overrides)
+	time.Sleep(1500 * time.Millisecond)
+	overrides["bootstrapper"] = first.Params.P2pAddress
+	second, stopSecond := startServer(t, 88, overrides)
+	servers := []*service.Server{first, second}
+	assert.Empty(t, waitRing(servers, 10*time.Second))
+
+	// the fingers are fixed every 20ms at first, then the interval doubles every 10 rounds up to 320ms, which takes
+	// about 3s once the ring is stable
+	time.Sleep(4 * time.Second)
+	stable := lookupRate(servers, 2*time.Second)
+	assert.Greater(t, stable, 0.0)
+
+	// the interval falls back to 20ms once a new node changes the ring
+	third, stopThird := startServer(t, 89, overrides)
+	assert.Empty(t, waitRing([]*service.Server{first, second, third}, 10*time.Second))
+	changed := lookupRate(servers, 500*time.Millisecond)
+	assert.Greater(t, changed, 4*stable, "%v lookups/s after the change, %v lookups/s while stable", changed, stable)
+
+	assert.Nil(t, stopThird())
+	assert.Nil(t, stopSecond())
+	assert.Nil(t, stopFirst())
+}
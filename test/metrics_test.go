@@ -0,0 +1,67 @@
+package test
+
+import (
+	"DHT/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scrape returns the metrics served on the given HTTP address.
+func scrape(t *testing.T, address string) string {
+	resp, err := http.Get("http://" + address + "/metrics")
+	if !assert.Nil(t, err) {
+		return ""
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	seed, stopSeed := startServer(t, 57, map[string]string{"http_address": "127.0.0.1:7573"})
+	_, stopOther := startServer(t, 58, map[string]string{"bootstrapper": "127.0.0.1:7572"})
+	time.Sleep(2 * time.Second)
+
+	c := client.NewClient(seed.Params.ApiAddress)
+	assert.NotNil(t, c)
+	key := []byte("metrics")
+	c.Put(key, []byte("value"), 10, 1)
+	time.Sleep(500 * time.Millisecond)
+	_, ok, _ := c.Get(key)
+	assert.True(t, ok)
+
+	metrics := scrape(t, "127.0.0.1:7573")
+	for _, line := range []string{
+		`dht_api_request_duration_seconds_count{response="NONE",type="DHT_PUT"} 1`,
+		`dht_api_request_duration_seconds_count{response="DHT_SUCCESS",type="DHT_GET"} 1`,
+		`dht_api_connections 1`,
+		`dht_ring_size_estimate 2`,
+		`dht_lookups_total{result="ok"}`,
+		`dht_lookup_hops_count`,
+		`dht_rpc_request_duration_seconds_count{code="OK",method="Notify"}`,
+		`dht_stored_keys`,
+		`dht_stored_bytes`,
+		`dht_p2p_connections`,
+	} {
+		assert.Contains(t, metrics, line)
+	}
+	c.Close()
+
+	assert.Nil(t, stopOther())
+	assert.Nil(t, stopSeed())
+	// the HTTP address is released once stopped
+	_, err := http.Get("http://127.0.0.1:7573/metrics")
+	assert.NotNil(t, err)
+}
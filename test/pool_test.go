@@ -0,0 +1,42 @@
+package test
+
+import (
+	"DHT/internal/chord"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/credentials/insecure"
+	"testing"
+)
+
+func TestConnPool(t *testing.T) {
+	pool := chord.NewConnPool(insecure.NewCredentials())
+	defer pool.Close()
+	a := chord.NewNode("127.0.0.1:7492")
+	b := chord.NewVirtualNode("127.0.0.1:7492", 1)
+	c := chord.NewNode("127.0.0.1:7493")
+	for _, node := range []*chord.Node{a, b, c} {
+		_, err := node.GetClient(pool)
+		assert.Nil(t, err)
+	}
+	// the virtual nodes of the same server share one connection
+	assert.Equal(t, 2, pool.Len())
+	assert.Equal(t, 3, pool.Refs())
+
+	// a node used by several goroutines keeps its connection until all of them close it
+	_, err := a.GetClient(pool)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, pool.Refs())
+	a.Close()
+	assert.Equal(t, 3, pool.Refs())
+	a.Close()
+	assert.Equal(t, 2, pool.Refs())
+	// closing it once more does nothing
+	a.Close()
+	assert.Equal(t, 2, pool.Refs())
+
+	b.Close()
+	c.Close()
+	// the references are all dropped without waiting for the garbage collector, while the released connections are
+	// kept until idle for long
+	assert.Equal(t, 0, pool.Refs())
+	assert.Equal(t, 2, pool.Len())
+}
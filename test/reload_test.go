@@ -0,0 +1,71 @@
+package test
+
+import (
+	"DHT/internal/logger"
+	"DHT/internal/service"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeReloadConfig writes a configuration file of the reloaded server, with the given extra lines.
+func writeReloadConfig(t *testing.T, file string, lines ...string) {
+	content := fmt.Sprintf(`[dht]
+p2p_address = 127.0.0.1:7462
+api_address = 127.0.0.1:7461
+log_file = reload.log
+data_file = data-reload.db
+ca_cert = ./config/ca-cert/ca-cert.pem
+hostkey = ./config/node0/hostkey.pem
+hostcert = ./config/node0/hostcert.pem
+%s
+`, strings.Join(lines, "\n"))
+	assert.Nil(t, os.WriteFile(file, []byte(content), 0644))
+}
+
+func TestReloadConfig(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	defer logger.SetLevel(zap.InfoLevel)
+	file := filepath.Join(t.TempDir(), "reload.ini")
+	writeReloadConfig(t, file, "check_interval = 100ms")
+	server, err := service.NewServer(file)
+	assert.Nil(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve(ctx)
+	}()
+	time.Sleep(time.Second)
+
+	// the runtime settings are applied, while the others are reported and kept
+	writeReloadConfig(t, file, "check_interval = 200ms", "rate_limit = 5", "log_level = warn",
+		"api_address = 127.0.0.1:7463", "max_replication = 5")
+	restart, err := server.Reload()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"api_address", "max_replication", "successor_list_size"}, restart)
+	assert.Equal(t, 200*time.Millisecond, server.P2pServer.RpcServer.Config().CheckInterval)
+	assert.Equal(t, 3, server.P2pServer.RpcServer.Config().SuccessorListSize)
+	assert.Equal(t, 5.0, server.ApiServer.Config().RateLimit)
+	assert.Equal(t, 0, server.ApiServer.Config().MaxReplication)
+	assert.Equal(t, "127.0.0.1:7461", server.CurrentParams().ApiAddress)
+	assert.Equal(t, zap.WarnLevel, server.CurrentParams().LogLevel)
+
+	// an invalid configuration is rejected as a whole
+	writeReloadConfig(t, file, "check_interval = 300ms", "lookup_mode = sideways")
+	_, err = server.Reload()
+	assert.NotNil(t, err)
+	assert.Equal(t, 200*time.Millisecond, server.P2pServer.RpcServer.Config().CheckInterval)
+
+	cancel()
+	assert.Nil(t, <-errs)
+}
@@ -1,16 +1,21 @@
 package test
 
 import (
+	"DHT/internal/api"
 	"DHT/internal/chord"
 	"DHT/internal/logger"
 	"DHT/internal/service"
+	"DHT/internal/utils"
 	"DHT/pkg/client"
+	"context"
+	"encoding/binary"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/zap"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -19,6 +24,7 @@ type ServiceTestSuite struct {
 	suite.Suite
 	servers []*service.Server
 	ring    []int
+	stops   []func() error // the functions stopping the servers, returning the errors of Serve
 }
 
 func (s *ServiceTestSuite) SetupSuite() {
@@ -37,29 +43,47 @@ func (s *ServiceTestSuite) SetupSuite() {
 }
 
 func (s *ServiceTestSuite) TearDownSuite() {
-	for _, server := range s.servers {
-		server.Stop()
+	for _, stop := range s.stops {
+		assert.Nil(s.T(), stop())
 	}
 	logger.Sync()
 }
 func (s *ServiceTestSuite) CreateServer(configurationFile string) *service.Server {
-	server := service.NewServer(configurationFile)
-	assert.NotEqual(s.T(), nil, server)
-	s.servers = append(s.servers, server)
+	server, err := service.NewServer(configurationFile)
+	assert.Nil(s.T(), err)
+	assert.NotNil(s.T(), server)
 	return server
 }
 
+// StartServer creates a server from the configuration file, and runs it in background, returning the function
+// stopping it.
+func (s *ServiceTestSuite) StartServer(configurationFile string) (*service.Server, func() error) {
+	server := s.CreateServer(configurationFile)
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve(ctx)
+	}()
+	stop := func() error {
+		cancel()
+		return <-errs
+	}
+	s.servers = append(s.servers, server)
+	s.stops = append(s.stops, stop)
+	return server, stop
+}
+
 func (s *ServiceTestSuite) Test00_StartFirstServer() {
-	go s.CreateServer("./config/node0/config0.ini").Serve()
+	s.StartServer("./config/node0/config0.ini")
 	time.Sleep(time.Second * 2)
 }
 
 func (s *ServiceTestSuite) Test01_JoinMoreServer() {
-	go s.CreateServer("./config/node1/config1.ini").Serve()
+	s.StartServer("./config/node1/config1.ini")
 	time.Sleep(time.Second * 2)
-	go s.CreateServer("./config/node2/config2.ini").Serve()
+	s.StartServer("./config/node2/config2.ini")
 	time.Sleep(time.Second * 2)
-	go s.CreateServer("./config/node3/config3.ini").Serve()
+	s.StartServer("./config/node3/config3.ini")
 	time.Sleep(time.Second * 2)
 	s.ring = []int{0, 3, 2, 1}
 }
@@ -67,16 +91,16 @@ func (s *ServiceTestSuite) Test01_JoinMoreServer() {
 func (s *ServiceTestSuite) Test02_CheckPredecessorAndSuccessor() {
 	for i := 0; i < 4; i++ {
 		a, b := s.ring[i], s.ring[(i+1)%4]
-		assert.Equal(s.T(), s.servers[b].P2pServer.RpcServer.Self.Addr, s.servers[a].P2pServer.RpcServer.Finger[0].Addr)
-		assert.Equal(s.T(), s.servers[a].P2pServer.RpcServer.Self.Addr, s.servers[b].P2pServer.RpcServer.Predecessor.Addr)
+		assert.Equal(s.T(), s.servers[b].P2pServer.RpcServer.Self.Addr, s.servers[a].P2pServer.RpcServer.Finger(0).Addr)
+		assert.Equal(s.T(), s.servers[a].P2pServer.RpcServer.Self.Addr, s.servers[b].P2pServer.RpcServer.Predecessor().Addr)
 	}
 }
 func (s *ServiceTestSuite) Test03_CheckStabilizedFingers() {
 	time.Sleep(10 * time.Second)
-	assert.Equal(s.T(), "127.0.0.1:7422", s.servers[0].P2pServer.RpcServer.Finger[chord.M-2].Addr)
-	assert.Equal(s.T(), "127.0.0.1:7412", s.servers[0].P2pServer.RpcServer.Finger[chord.M-1].Addr)
-	assert.Equal(s.T(), "127.0.0.1:7402", s.servers[1].P2pServer.RpcServer.Finger[chord.M-2].Addr)
-	assert.Equal(s.T(), "127.0.0.1:7432", s.servers[1].P2pServer.RpcServer.Finger[chord.M-1].Addr)
+	assert.Equal(s.T(), "127.0.0.1:7422", s.servers[0].P2pServer.RpcServer.Finger(chord.M-2).Addr)
+	assert.Equal(s.T(), "127.0.0.1:7412", s.servers[0].P2pServer.RpcServer.Finger(chord.M-1).Addr)
+	assert.Equal(s.T(), "127.0.0.1:7402", s.servers[1].P2pServer.RpcServer.Finger(chord.M-2).Addr)
+	assert.Equal(s.T(), "127.0.0.1:7432", s.servers[1].P2pServer.RpcServer.Finger(chord.M-1).Addr)
 }
 func (s *ServiceTestSuite) Test10_ApiPutGet() {
 	c := client.NewClient(s.servers[0].Params.ApiAddress)
@@ -101,6 +125,223 @@ func (s *ServiceTestSuite) Test10_ApiPutGet() {
 	c.Close()
 }
 
+func (s *ServiceTestSuite) Test11_ApiBatchPutGet() {
+	c := client.NewClient(s.servers[1].Params.ApiAddress)
+	assert.NotNil(s.T(), c)
+	var keys, values [][]byte
+	for i := 0; i < 100; i++ {
+		keys = append(keys, []byte(fmt.Sprintf("batch-key-%d", i)))
+		values = append(values, []byte(fmt.Sprintf("batch-value-%d", i)))
+	}
+	oks, err := c.BatchPut(keys, values, 5, 2)
+	assert.Nil(s.T(), err)
+	for _, ok := range oks {
+		assert.True(s.T(), ok)
+	}
+
+	// query more keys than put, so that some keys are not found
+	got, oks, err := c.BatchGet(append(keys, []byte("batch-key-none")))
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), len(keys)+1, len(got))
+	for i := range keys {
+		assert.True(s.T(), oks[i])
+		assert.Equal(s.T(), values[i], got[i])
+	}
+	assert.False(s.T(), oks[len(keys)])
+
+	// each key can be found by a single get as well
+	v, ok, _ := c.Get(keys[42])
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), values[42], v)
+
+	// more keys than a result can answer are refused by the client, and dropped by the server
+	_, _, err = c.BatchGet(make([][]byte, api.MAX_BATCH_GET_KEYS+1))
+	assert.NotNil(s.T(), err)
+	msgBody := make([]byte, 4+(api.MAX_BATCH_GET_KEYS+1)*api.KEY_SIZE)
+	binary.BigEndian.PutUint16(msgBody[0:2], api.MAX_BATCH_GET_KEYS+1)
+	msgType, msgBody := s.servers[1].ApiServer.ProcessMessage(api.DHT_BATCH_GET, msgBody)
+	assert.Equal(s.T(), api.MsgType(0), msgType)
+	assert.Nil(s.T(), msgBody)
+
+	c.Close()
+}
+
+func (s *ServiceTestSuite) Test12_ApiCondPut() {
+	c := client.NewClient(s.servers[2].Params.ApiAddress)
+	assert.NotNil(s.T(), c)
+	key := []byte("lease")
+
+	ok, version, _, err := c.PutIfAbsent(key, []byte("owner-a"), 10, 2)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), uint64(1), version)
+	ok, version, current, err := c.PutIfAbsent(key, []byte("owner-b"), 10, 2)
+	assert.Nil(s.T(), err)
+	assert.False(s.T(), ok)
+	assert.Equal(s.T(), uint64(1), version)
+	assert.Equal(s.T(), []byte("owner-a"), current)
+
+	ok, _, _, err = c.CompareAndSwap(key, []byte("owner-b"), []byte("owner-c"), 10, 2)
+	assert.Nil(s.T(), err)
+	assert.False(s.T(), ok)
+	ok, version, _, err = c.CompareAndSwap(key, []byte("owner-a"), []byte("owner-b"), 10, 2)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), uint64(2), version)
+
+	ok, _, _, err = c.CompareAndSwapVersion(key, 1, []byte("owner-c"), 10, 2)
+	assert.Nil(s.T(), err)
+	assert.False(s.T(), ok)
+	ok, version, _, err = c.CompareAndSwapVersion(key, 2, []byte("owner-c"), 10, 2)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), uint64(3), version)
+
+	v, ok, _ := c.Get(key)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), []byte("owner-c"), v)
+
+	c.Close()
+}
+
+func (s *ServiceTestSuite) Test13_ApiSubscribe() {
+	sub := client.NewClient(s.servers[3].Params.ApiAddress)
+	assert.NotNil(s.T(), sub)
+	c := client.NewClient(s.servers[0].Params.ApiAddress)
+	assert.NotNil(s.T(), c)
+	key := []byte("watched")
+	assert.Nil(s.T(), sub.Subscribe(key))
+	time.Sleep(time.Millisecond * 500)
+
+	c.Put(key, []byte("value"), 2, 2)
+	notifications := make(chan *client.Notification, 2)
+	go func() {
+		for i := 0; i < 2; i++ {
+			if n, err := sub.ReceiveNotification(); err == nil {
+				notifications <- n
+			}
+		}
+	}()
+	for _, typ := range []uint8{api.NOTIFY_PUT, api.NOTIFY_EXPIRE} {
+		select {
+		case n := <-notifications:
+			assert.Equal(s.T(), typ, n.Type)
+			assert.Equal(s.T(), []byte("value"), n.Value)
+			assert.Equal(s.T(), uint64(1), n.Version)
+		case <-time.After(5 * time.Second):
+			s.T().Fatal("notification timeout")
+		}
+	}
+
+	c.Close()
+	sub.Close()
+}
+
+func (s *ServiceTestSuite) Test14_ApiTouch() {
+	c := client.NewClient(s.servers[1].Params.ApiAddress)
+	assert.NotNil(s.T(), c)
+	key := []byte("touched")
+	ok, err := c.Touch(key, 5, 2)
+	assert.Nil(s.T(), err)
+	assert.False(s.T(), ok)
+
+	c.Put(key, []byte("value"), 2, 2)
+	time.Sleep(time.Second)
+	ok, err = c.Touch(key, 5, 2)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), ok)
+
+	// the key would have expired without the touch
+	time.Sleep(time.Second * 2)
+	v, ok, _ := c.Get(key)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), []byte("value"), v)
+
+	c.Close()
+
+	// a message too short to hold a key is dropped
+	msgType, msgBody := s.servers[1].ApiServer.ProcessMessage(api.DHT_TOUCH, []byte{0, 5, 1, 0})
+	assert.Equal(s.T(), api.MsgType(0), msgType)
+	assert.Nil(s.T(), msgBody)
+}
+
+func (s *ServiceTestSuite) Test15_ApiTrace() {
+	c := client.NewClient(s.servers[0].Params.ApiAddress)
+	assert.NotNil(s.T(), c)
+	lookups := s.servers[0].P2pServer.RpcServer.LookupStats().Lookups
+	for i := 0; i < 10; i++ {
+		key := make([]byte, api.KEY_SIZE)
+		copy(key, fmt.Sprintf("trace-%d", i))
+		trace, err := c.Trace(key)
+		assert.Nil(s.T(), err)
+		expected, err := s.servers[1].P2pServer.RpcServer.Lookup(context.Background(), utils.SHA1(key))
+		assert.Nil(s.T(), err)
+		assert.Equal(s.T(), expected.Addr, trace.SuccessorAddr)
+		assert.Equal(s.T(), expected.Id, trace.SuccessorId)
+		assert.LessOrEqual(s.T(), len(trace.Hops), 3)
+	}
+	stats := s.servers[0].P2pServer.RpcServer.LookupStats()
+	assert.GreaterOrEqual(s.T(), stats.Lookups, lookups+10)
+	total := stats.Failures
+	for _, n := range stats.HopCounts {
+		total += n
+	}
+	assert.Equal(s.T(), stats.Lookups, total)
+	c.Close()
+
+	// a message too short to hold a key is dropped
+	msgType, msgBody := s.servers[0].ApiServer.ProcessMessage(api.DHT_TRACE, []byte("short"))
+	assert.Equal(s.T(), api.MsgType(0), msgType)
+	assert.Nil(s.T(), msgBody)
+}
+
+func (s *ServiceTestSuite) Test16_ConcurrentLookups() {
+	// lookups and puts race with the maintenance tasks updating the ring state, checked by go test -race
+	var wg sync.WaitGroup
+	for i := range s.servers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			server := s.servers[i].P2pServer.RpcServer
+			c := client.NewClient(s.servers[i].Params.ApiAddress)
+			assert.NotNil(s.T(), c)
+			defer c.Close()
+			for j := 0; j < 20; j++ {
+				key := []byte(fmt.Sprintf("concurrent-%d-%d", i, j))
+				_, err := server.Lookup(context.Background(), utils.SHA1(key))
+				assert.Nil(s.T(), err)
+				c.Put(key, key, 5, 2)
+				_ = server.GetInfoString()
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func (s *ServiceTestSuite) Test17_RestartServer() {
+	c := client.NewClient(s.servers[0].Params.ApiAddress)
+	assert.NotNil(s.T(), c)
+	key := []byte("restarted")
+	c.Put(key, []byte("value"), 60, 2)
+	c.Close()
+	time.Sleep(time.Second)
+
+	// the server can be stopped and run again within the process, reusing its addresses and data file
+	assert.Nil(s.T(), s.stops[3]())
+	server, stop := s.StartServer("./config/node3/config3.ini")
+	s.servers[3], s.stops[3] = server, stop
+	s.servers, s.stops = s.servers[:4], s.stops[:4]
+	time.Sleep(time.Second * 3)
+
+	c = client.NewClient(server.Params.ApiAddress)
+	assert.NotNil(s.T(), c)
+	v, ok, err := c.Get(key)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), []byte("value"), v)
+	c.Close()
+}
+
 func TestServiceTestSuit(t *testing.T) {
 	suite.Run(t, new(ServiceTestSuite))
 }
@@ -0,0 +1,127 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// writeDhtConfig writes a configuration file of the dht with the certificates of node0 into the directory, with the
+// given extra lines, and returns its path.
+func writeDhtConfig(t *testing.T, dir string, name string, extra ...string) string {
+	lines := []string{
+		"[dht]",
+		"p2p_address = 127.0.0.1:7952",
+		"api_address = 127.0.0.1:7951",
+		"log_file = signal.log",
+		"data_file = signal.db",
+	}
+	for key, path := range map[string]string{
+		"ca_cert":  "./config/ca-cert/ca-cert.pem",
+		"hostkey":  "./config/node0/hostkey.pem",
+		"hostcert": "./config/node0/hostcert.pem",
+	} {
+		abs, err := filepath.Abs(path)
+		assert.Nil(t, err)
+		lines = append(lines, key+" = "+abs)
+	}
+	file := filepath.Join(dir, name)
+	assert.Nil(t, os.WriteFile(file, []byte(strings.Join(append(lines, extra...), "\n")+"\n"), 0644))
+	return file
+}
+
+// exitCode waits for the command to exit, up to the given timeout, and returns its exit code.
+func exitCode(t *testing.T, cmd *exec.Cmd, timeout time.Duration) int {
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	select {
+	case err := <-done:
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		assert.Nil(t, err)
+		return 0
+	case <-time.After(timeout):
+		cmd.Process.Kill()
+		t.Fatal("the dht has not exited in time")
+		return -1
+	}
+}
+
+// startDht starts the dht built at the given path in the directory, and waits for its API address to accept.
+func startDht(t *testing.T, bin string, dir string, config string) *exec.Cmd {
+	cmd := exec.Command(bin, "-c", config)
+	cmd.Dir = dir
+	assert.Nil(t, cmd.Start())
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		if conn, err := net.Dial("tcp", "127.0.0.1:7951"); err == nil {
+			conn.Close()
+			return cmd
+		}
+	}
+	t.Fatal("the dht has not started in time")
+	return cmd
+}
+
+func TestSignalsAndExitCodes(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "dht")
+	build := exec.Command("go", "build", "-o", bin, "./cmd/dht")
+	if out, err := build.CombinedOutput(); !assert.Nil(t, err, string(out)) {
+		return
+	}
+
+	// an invalid configuration is reported by -check-config and by running alike
+	invalid := writeDhtConfig(t, dir, "invalid.ini", "lookup_mode = bogus")
+	for _, args := range [][]string{{"-c", invalid, "-check-config"}, {"-c", invalid}} {
+		cmd := exec.Command(bin, args...)
+		cmd.Dir = dir
+		assert.Nil(t, cmd.Start())
+		assert.Equal(t, 2, exitCode(t, cmd, 10*time.Second))
+	}
+	stdout := &bytes.Buffer{}
+	cmd := exec.Command(bin, "-c", writeDhtConfig(t, dir, "signal.ini"), "-check-config")
+	cmd.Dir, cmd.Stdout = dir, stdout
+	assert.Nil(t, cmd.Start())
+	assert.Equal(t, 0, exitCode(t, cmd, 10*time.Second))
+	assert.Contains(t, stdout.String(), "p2p_address = 127.0.0.1:7952")
+
+	// SIGHUP reopens the log file moved away, and the dht keeps running until SIGTERM
+	cmd = startDht(t, bin, dir, filepath.Join(dir, "signal.ini"))
+	log := filepath.Join(dir, "logs", "signal.log")
+	assert.Nil(t, os.Rename(log, log+".1"))
+	assert.Nil(t, cmd.Process.Signal(syscall.SIGHUP))
+	reopened := false
+	for deadline := time.Now().Add(5 * time.Second); !reopened && time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		_, err := os.Stat(log)
+		reopened = err == nil
+	}
+	assert.True(t, reopened)
+	conn, err := net.Dial("tcp", "127.0.0.1:7951")
+	if assert.Nil(t, err) {
+		conn.Close()
+	}
+	assert.Nil(t, cmd.Process.Signal(syscall.SIGTERM))
+	assert.Equal(t, 0, exitCode(t, cmd, 15*time.Second))
+
+	// SIGINT stops the dht as well, which is forced if not drained within the drain timeout
+	cmd = startDht(t, bin, dir, writeDhtConfig(t, dir, "drain.ini", "drain_timeout = 1ns"))
+	assert.Nil(t, cmd.Process.Signal(syscall.SIGINT))
+	assert.Equal(t, 3, exitCode(t, cmd, 15*time.Second))
+}
@@ -26,10 +26,15 @@ func (s *StorageTestSuite) SetupSuite() {
 		panic(err)
 	}
 
-	s.storage = storage.NewStorage("data.db")
+	var err error
+	s.storage, err = storage.NewStorage("data.db")
+	if err != nil {
+		panic(err)
+	}
 }
 
 func (s *StorageTestSuite) TearDownSuite() {
+	assert.Nil(s.T(), s.storage.Close())
 	logger.Sync()
 }
 
@@ -50,6 +55,99 @@ func (s *StorageTestSuite) Test00() {
 	assert.Equal(s.T(), false, ok)
 }
 
+func (s *StorageTestSuite) Test01_Versions() {
+	key := []byte("key3")
+	assert.Equal(s.T(), uint64(1), s.storage.Put(key, []byte("v1"), time.Second))
+	assert.Equal(s.T(), uint64(2), s.storage.Put(key, []byte("v2"), time.Second))
+	value, version, ok := s.storage.GetVersion(key)
+	assert.Equal(s.T(), []byte("v2"), value)
+	assert.Equal(s.T(), uint64(2), version)
+	assert.True(s.T(), ok)
+
+	// replicas never go back to an older version
+	s.storage.PutVersion(key, []byte("v1"), time.Second, 1)
+	value, _ = s.storage.Get(key)
+	assert.Equal(s.T(), []byte("v2"), value)
+	s.storage.PutVersion(key, []byte("v5"), time.Second, 5)
+	value, version, _ = s.storage.GetVersion(key)
+	assert.Equal(s.T(), []byte("v5"), value)
+	assert.Equal(s.T(), uint64(5), version)
+}
+
+func (s *StorageTestSuite) Test02_PutIf() {
+	key := []byte("key4")
+	version, _, ok := s.storage.PutIf(key, []byte("a"), time.Second, 1, storage.Condition{Kind: storage.COND_ABSENT})
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), uint64(1), version)
+	version, current, ok := s.storage.PutIf(key, []byte("b"), time.Second, 1, storage.Condition{Kind: storage.COND_ABSENT})
+	assert.False(s.T(), ok)
+	assert.Equal(s.T(), uint64(1), version)
+	assert.Equal(s.T(), []byte("a"), current)
+
+	_, current, ok = s.storage.PutIf(key, []byte("b"), time.Second, 1, storage.Condition{Kind: storage.COND_VALUE, Value: []byte("x")})
+	assert.False(s.T(), ok)
+	assert.Equal(s.T(), []byte("a"), current)
+	version, _, ok = s.storage.PutIf(key, []byte("b"), time.Second, 1, storage.Condition{Kind: storage.COND_VALUE, Value: []byte("a")})
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), uint64(2), version)
+
+	_, _, ok = s.storage.PutIf(key, []byte("c"), time.Second, 1, storage.Condition{Kind: storage.COND_VERSION, Version: 1})
+	assert.False(s.T(), ok)
+	version, _, ok = s.storage.PutIf(key, []byte("c"), time.Second, 1, storage.Condition{Kind: storage.COND_VERSION, Version: 2})
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), uint64(3), version)
+	value, _ := s.storage.Get(key)
+	assert.Equal(s.T(), []byte("c"), value)
+}
+
+func (s *StorageTestSuite) Test03_Touch() {
+	key := []byte("key5")
+	assert.False(s.T(), s.storage.Touch(key, time.Second))
+	s.storage.Put(key, []byte("v"), time.Second)
+	assert.True(s.T(), s.storage.Touch(key, 3*time.Second))
+	time.Sleep(time.Second + time.Millisecond)
+	value, version, ok := s.storage.GetVersion(key)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), []byte("v"), value)
+	assert.Equal(s.T(), uint64(1), version)
+}
+
+func (s *StorageTestSuite) Test04_Items() {
+	key := []byte("key6")
+	assert.Equal(s.T(), uint64(1), s.storage.PutReplica(key, []byte("v1"), 3*time.Second, 0, 2))
+	assert.Equal(s.T(), uint64(4), s.storage.PutReplica(key, []byte("v4"), 3*time.Second, 4, 3))
+	found := false
+	for _, item := range s.storage.Items() {
+		if string(item.Key) == string(key) {
+			found = true
+			assert.Equal(s.T(), []byte("v4"), item.Value)
+			assert.Equal(s.T(), uint64(4), item.Version)
+			assert.Equal(s.T(), int32(3), item.Replication)
+			assert.True(s.T(), item.TTL > 0 && item.TTL <= 3*time.Second)
+		}
+	}
+	assert.True(s.T(), found)
+}
+
+func (s *StorageTestSuite) Test05_PutIfReplication() {
+	key := []byte("key7")
+	_, _, ok := s.storage.PutIf(key, []byte("a"), 3*time.Second, 2, storage.Condition{Kind: storage.COND_ABSENT})
+	assert.True(s.T(), ok)
+	_, _, ok = s.storage.PutIf(key, []byte("b"), 3*time.Second, 3, storage.Condition{Kind: storage.COND_VALUE, Value: []byte("a")})
+	assert.True(s.T(), ok)
+	found := false
+	for _, item := range s.storage.Items() {
+		if string(item.Key) == string(key) {
+			found = true
+			assert.Equal(s.T(), []byte("b"), item.Value)
+			assert.Equal(s.T(), uint64(2), item.Version)
+			// the replication is kept, so that the key is re-replicated once its replicas are lost
+			assert.Equal(s.T(), int32(3), item.Replication)
+		}
+	}
+	assert.True(s.T(), found)
+}
+
 func TestStorageTestSuit(t *testing.T) {
 	suite.Run(t, new(StorageTestSuite))
 }
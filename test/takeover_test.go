@@ -0,0 +1,88 @@
+package test
+
+import (
+	"DHT/internal/api"
+	"DHT/internal/service"
+	"DHT/internal/storage"
+	"DHT/internal/utils"
+	"DHT/pkg/client"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// storedItems returns the items in the storage of the server, keyed by key.
+func storedItems(server *service.Server) map[string]*storage.Item {
+	items := make(map[string]*storage.Item)
+	for _, item := range server.Storage.Items() {
+		items[string(item.Key)] = item
+	}
+	return items
+}
+
+func TestTakeOverLeavingNode(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	first, stopFirst := startServer(t, 97, nil)
+	time.Sleep(500 * time.Millisecond)
+	bootstrapper := map[string]string{"bootstrapper": first.Params.P2pAddress}
+	second, stopSecond := startServer(t, 98, bootstrapper)
+	leaving, stopLeaving := startServer(t, 99, bootstrapper)
+	servers := []*service.Server{first, second, leaving}
+	assert.Empty(t, waitRing(servers, 15*time.Second))
+
+	// each key is stored on its owner and on the next server
+	c := client.NewClient(first.Params.ApiAddress)
+	if !assert.NotNil(t, c) {
+		return
+	}
+	var keys [][]byte
+	for i := 0; i < 30; i++ {
+		key := make([]byte, api.KEY_SIZE)
+		copy(key, fmt.Sprintf("takeover%d", i))
+		c.Put(key, []byte("value"), 60, 2)
+		keys = append(keys, key)
+	}
+	c.Close()
+	time.Sleep(time.Second)
+	var owned [][]byte
+	for _, key := range keys {
+		if expectedSuccessor(servers, utils.SHA1(key)).Addr == leaving.Params.P2pAddress {
+			owned = append(owned, key)
+		}
+	}
+	assert.NotEmpty(t, owned)
+
+	// the leaving node fails without handing over its keys, whose replicas on its successor are taken over
+	assert.Nil(t, stopLeaving())
+	servers = servers[:2]
+	assert.Empty(t, waitRing(servers, 15*time.Second))
+	time.Sleep(time.Second)
+	for _, key := range owned {
+		owner := first
+		if expectedSuccessor(servers, utils.SHA1(key)).Addr == second.Params.P2pAddress {
+			owner = second
+		}
+		replica := first
+		if owner == first {
+			replica = second
+		}
+		// the new owner holds the key for both replicas again, and has restored the one lost on the next server
+		if item, ok := storedItems(owner)[string(key)]; assert.True(t, ok, "%q is not taken over", key) {
+			assert.Equal(t, []byte("value"), item.Value)
+			assert.Equal(t, int32(2), item.Replication)
+		}
+		if item, ok := storedItems(replica)[string(key)]; assert.True(t, ok, "the replica of %q is not restored", key) {
+			assert.Equal(t, int32(1), item.Replication)
+		}
+	}
+
+	assert.Nil(t, stopSecond())
+	assert.Nil(t, stopFirst())
+}
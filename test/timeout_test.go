@@ -0,0 +1,62 @@
+package test
+
+import (
+	"DHT/internal/api"
+	"DHT/pkg/client"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// assertTimeout asserts that the error tells the request of the given message type has timed out.
+func assertTimeout(t *testing.T, msgType api.MsgType, err error) {
+	var e *client.RequestError
+	if assert.True(t, errors.As(err, &e), "%v is not a RequestError", err) {
+		assert.Equal(t, msgType, e.MsgType)
+		assert.Equal(t, api.ERR_TIMEOUT, e.Code)
+	}
+}
+
+func TestApiTimeout(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	// the calls to the responsible node cannot finish in time, even if it is the local one
+	server, stop := startServer(t, 94, map[string]string{"get_timeout": "1ns", "put_timeout": "1ns"})
+	time.Sleep(1500 * time.Millisecond)
+	c := client.NewClient(server.Params.ApiAddress)
+	if !assert.NotNil(t, c) {
+		return
+	}
+	defer c.Close()
+
+	_, ok, err := c.Get([]byte("timeout"))
+	assert.False(t, ok)
+	assertTimeout(t, api.DHT_GET, err)
+	_, err = c.Touch([]byte("timeout"), 10, 1)
+	assertTimeout(t, api.DHT_TOUCH, err)
+	_, _, _, err = c.PutIfAbsent([]byte("timeout"), []byte("value"), 10, 1)
+	assertTimeout(t, api.DHT_PUT_IF_ABSENT, err)
+
+	// the connection is still usable after the timeouts, and the put timed out has not stored the key
+	c.Put([]byte("timeout"), []byte("value"), 10, 1)
+	_, ok, err = c.Get([]byte("timeout"))
+	assert.False(t, ok)
+	assertTimeout(t, api.DHT_GET, err)
+	assert.Empty(t, server.Storage.Items())
+
+	// the keys of a batch not done in time are failed, rather than blocking the batch
+	oks, err := c.BatchPut([][]byte{[]byte("batch0"), []byte("batch1")}, [][]byte{[]byte("value"), []byte("value")}, 10, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []bool{false, false}, oks)
+	_, oks, err = c.BatchGet([][]byte{[]byte("batch0"), []byte("batch1")})
+	assert.Nil(t, err)
+	assert.Equal(t, []bool{false, false}, oks)
+
+	assert.Nil(t, stop())
+}
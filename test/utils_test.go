@@ -43,3 +43,11 @@ func TestSHA1(t *testing.T) {
 	sum := sha1.Sum(data)
 	assert.Equal(t, sum[:], utils.SHA1(data))
 }
+
+func TestNodeId(t *testing.T) {
+	addr := "127.0.0.1:7402"
+	assert.Equal(t, utils.SHA1([]byte(addr)), utils.NodeId(addr, 0))
+	assert.Equal(t, utils.SHA1([]byte(addr+"#2")), utils.NodeId(addr, 2))
+	assert.Equal(t, true, utils.CheckIdentity(utils.NodeId(addr, 2), addr, 2))
+	assert.Equal(t, false, utils.CheckIdentity(utils.NodeId(addr, 2), addr, 1))
+}
@@ -0,0 +1,81 @@
+package test
+
+import (
+	"DHT/internal/api"
+	"DHT/internal/service"
+	"DHT/pkg/client"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVirtualNodesRing(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	overrides := map[string]string{"virtual_nodes": "4"}
+	var servers []*service.Server
+	var stops []func() error
+	for _, i := range []int{65, 66, 67} {
+		server, stop := startServer(t, i, overrides)
+		servers, stops = append(servers, server), append(stops, stop)
+		// the later servers join through the first one
+		overrides = map[string]string{"virtual_nodes": "4", "bootstrapper": "127.0.0.1:7652"}
+		time.Sleep(1500 * time.Millisecond)
+	}
+	assert.Empty(t, waitRing(servers, 15*time.Second))
+	for _, stop := range stops {
+		assert.Nil(t, stop())
+	}
+}
+
+func TestVirtualNodesReplication(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	// with many virtual nodes per server, the next ones of a virtual node are often of the same server
+	overrides := map[string]string{"virtual_nodes": "8", "max_replication": "2"}
+	first, stopFirst := startServer(t, 75, overrides)
+	time.Sleep(500 * time.Millisecond)
+	overrides["bootstrapper"] = first.Params.P2pAddress
+	second, stopSecond := startServer(t, 76, overrides)
+	servers := []*service.Server{first, second}
+	assert.Empty(t, waitRing(servers, 15*time.Second))
+	time.Sleep(2 * time.Second)
+
+	// the successor list of each virtual node still reaches the other server
+	for _, server := range servers {
+		for _, vnode := range server.P2pServer.VirtualServers {
+			other := false
+			for _, node := range vnode.Status().SuccessorList {
+				other = other || node.Addr != server.Params.P2pAddress
+			}
+			assert.True(t, other, "%s has no successor of another server", vnode.Self.Ident())
+		}
+	}
+
+	// so all the keys are replicated to both servers, wherever they land
+	c := client.NewClient(first.Params.ApiAddress)
+	defer c.Close()
+	for i := 0; i < 50; i++ {
+		c.Put([]byte(fmt.Sprintf("replicated%d", i)), []byte("value"), 60, 2)
+	}
+	time.Sleep(time.Second)
+	for _, server := range servers {
+		for i := 0; i < 50; i++ {
+			key := make([]byte, api.KEY_SIZE)
+			copy(key, fmt.Sprintf("replicated%d", i))
+			_, ok := server.Storage.Get(key)
+			assert.True(t, ok, "replicated%d is missing on %s", i, server.Params.P2pAddress)
+		}
+	}
+	assert.Nil(t, stopSecond())
+	assert.Nil(t, stopFirst())
+}
@@ -0,0 +1,138 @@
+package test
+
+import (
+	"DHT/internal/api"
+	"DHT/internal/chord"
+	"DHT/internal/chord/proto"
+	"DHT/pkg/client"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStopWithRemoteWatch(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	watched, stopWatched := startServer(t, 68, nil)
+	watcher, stopWatcher := startServer(t, 69, map[string]string{"bootstrapper": watched.Params.P2pAddress})
+	time.Sleep(time.Second)
+
+	node := chord.NewNode(watched.Params.P2pAddress)
+	c, err := node.GetClient(watcher.P2pServer.RpcServer.Pool)
+	assert.Nil(t, err)
+	defer node.Close()
+	stream, err := c.Watch(context.Background(), &proto.WatchReq{Key: []byte("watched")})
+	assert.Nil(t, err)
+	time.Sleep(200 * time.Millisecond)
+
+	// the server stops without waiting for the remote watcher to leave
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- stopWatched()
+	}()
+	select {
+	case err := <-stopped:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server is not stopped while a remote watch is open")
+	}
+	_, err = stream.Recv()
+	assert.NotNil(t, err)
+
+	assert.Nil(t, stopWatcher())
+}
+
+func TestCloseSubscriberWhileNotified(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	server, stop := startServer(t, 71, nil)
+	time.Sleep(500 * time.Millisecond)
+	key := []byte("notified")
+	// the key keeps changing, so that the notifications are being sent while the subscribers leave
+	done := make(chan struct{})
+	writing := make(chan struct{})
+	go func() {
+		defer close(writing)
+		c := client.NewClient(server.Params.ApiAddress)
+		defer c.Close()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				c.Put(key, []byte("value"), 10, 1)
+			}
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		sub := client.NewClient(server.Params.ApiAddress)
+		assert.NotNil(t, sub)
+		assert.Nil(t, sub.Subscribe(key))
+		time.Sleep(50 * time.Millisecond)
+		sub.Close()
+	}
+	close(done)
+	<-writing
+	assert.Eventually(t, func() bool {
+		return server.ApiServer.NumConnections() == 0
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.Nil(t, stop())
+}
+
+func TestNotifyLargeValue(t *testing.T) {
+	if wd, err := os.Getwd(); err == nil {
+		if strings.HasSuffix(wd, "test") {
+			os.Chdir("..")
+		}
+	}
+	server, stop := startServer(t, 70, nil)
+	time.Sleep(500 * time.Millisecond)
+	sub := client.NewClient(server.Params.ApiAddress)
+	assert.NotNil(t, sub)
+	c := client.NewClient(server.Params.ApiAddress)
+	assert.NotNil(t, c)
+	key := []byte("large")
+	assert.Nil(t, sub.Subscribe(key))
+	time.Sleep(500 * time.Millisecond)
+
+	// the largest value of a DHT_PUT doesn't fit in a notification, so only the change is notified
+	large := make([]byte, api.MAX_MSG_SIZE-4-4-api.KEY_SIZE)
+	notifications := make(chan *client.Notification, 2)
+	go func() {
+		for i := 0; i < 2; i++ {
+			if n, err := sub.ReceiveNotification(); err == nil {
+				notifications <- n
+			}
+		}
+	}()
+	for _, value := range [][]byte{large, []byte("small")} {
+		c.Put(key, value, 60, 1)
+		select {
+		case n := <-notifications:
+			assert.Equal(t, api.NOTIFY_PUT, n.Type)
+			assert.Equal(t, len(value) == len(large), n.Omitted)
+			if !n.Omitted {
+				assert.Equal(t, value, n.Value)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("notification timeout")
+		}
+	}
+	v, ok, err := c.Get(key)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("small"), v)
+
+	c.Close()
+	sub.Close()
+	assert.Nil(t, stop())
+}